@@ -0,0 +1,155 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	ActionCreate  AuditAction = "create"
+	ActionUpdate  AuditAction = "update"
+	ActionPatch   AuditAction = "patch"
+	ActionDelete  AuditAction = "delete"
+	ActionRestore AuditAction = "restore"
+)
+
+// AuditRecord is a single change of a person along with who made it.
+type AuditRecord struct {
+	ID        int64       `json:"id"`
+	PersonID  int         `json:"personId"`
+	Action    AuditAction `json:"action"`
+	Actor     string      `json:"actor"`
+	RequestID string      `json:"requestId,omitempty"`
+	Changes   Changes     `json:"changes"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// Changes maps JSON names of person fields to their values before and after a change.
+type Changes map[string]FieldChange
+
+type FieldChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// Diff returns the fields that differ between two states of a person.
+// A nil state stands for a person that doesn't exist.
+func Diff(before, after *Person) (Changes, error) {
+	old, err := personFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := personFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(Changes)
+
+	for name, value := range cur {
+		if prev, ok := old[name]; !ok || !bytes.Equal(prev, value) {
+			changes[name] = FieldChange{Before: prev, After: value}
+		}
+	}
+
+	for name, prev := range old {
+		if _, ok := cur[name]; !ok {
+			changes[name] = FieldChange{Before: prev}
+		}
+	}
+
+	return changes, nil
+}
+
+// PersonAsOf reconstructs a person by replaying its history, ordered from the oldest change, up to the given time.
+func PersonAsOf(history []AuditRecord, t time.Time) (*Person, error) {
+	state := make(map[string]json.RawMessage)
+	found := false
+
+	for _, rec := range history {
+		if rec.CreatedAt.After(t) {
+			break
+		}
+
+		found = true
+
+		for name, change := range rec.Changes {
+			if isNull(change.After) {
+				delete(state, name)
+			} else {
+				state[name] = change.After
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: person didn't exist at %s", ErrNotFound, t.Format(time.RFC3339))
+	}
+
+	doc, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	var person Person
+
+	if err := json.Unmarshal(doc, &person); err != nil {
+		return nil, fmt.Errorf("can't reconstruct person from history: %w", err)
+	}
+
+	return &person, nil
+}
+
+func personFields(p *Person) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+
+	if p == nil {
+		return fields, nil
+	}
+
+	doc, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func isNull(value json.RawMessage) bool {
+	return len(value) == 0 || string(value) == "null"
+}
+
+type actorKey struct{}
+
+type requestIDKey struct{}
+
+// ContextWithActor attaches the name of whoever makes the request to ctx, it ends up in audit records.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+
+	return actor
+}
+
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
@@ -0,0 +1,59 @@
+package app
+
+import "fmt"
+
+// MaxBulkSize is the maximum number of items in a single bulk request.
+const MaxBulkSize = 1000
+
+type BulkMode string
+
+const (
+	// BulkAtomic applies all items in a single transaction, none of them is applied if any fails.
+	BulkAtomic BulkMode = "atomic"
+	// BulkPerItem applies every item separately, failed items don't affect the others.
+	BulkPerItem BulkMode = "per_item"
+)
+
+func (m BulkMode) Valid() bool {
+	return m == BulkAtomic || m == BulkPerItem
+}
+
+// PersonRef identifies a person along with the version a change is based on.
+type PersonRef struct {
+	ID      int `json:"id"`
+	Version int `json:"version"`
+}
+
+// BulkResult is the outcome of a single item of a bulk request.
+type BulkResult struct {
+	Person *Person
+	Err    error
+}
+
+// BatchItemError tells which item has failed a batch repository call.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %s", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// CheckBulk validates the size and the mode of a bulk request.
+func CheckBulk(size int, mode BulkMode) error {
+	switch {
+	case !mode.Valid():
+		return fmt.Errorf("%w: bulk mode must be %s or %s, got %q", ErrValidation, BulkAtomic, BulkPerItem, mode)
+	case size == 0:
+		return fmt.Errorf("%w: bulk request has no items", ErrValidation)
+	case size > MaxBulkSize:
+		return fmt.Errorf("%w: bulk request has %d items, at most %d are allowed", ErrValidation, size, MaxBulkSize)
+	}
+
+	return nil
+}
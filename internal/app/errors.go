@@ -0,0 +1,19 @@
+package app
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("person not found")
+	ErrEmailConflict = errors.New("email address is already in use")
+	ErrValidation    = errors.New("invalid request data")
+	ErrUnavailable   = errors.New("storage is unavailable")
+	// ErrTimeout is returned when the storage hasn't answered in time, which isn't taken for an outage.
+	ErrTimeout = errors.New("storage has timed out")
+	// ErrVersionMismatch is returned when a person was changed since the client has read it.
+	ErrVersionMismatch = errors.New("person has been modified")
+	ErrNotDeleted      = errors.New("person is not deleted")
+	// ErrConcurrentUpdate is returned when a transaction keeps conflicting with concurrent ones, so it may be retried later.
+	ErrConcurrentUpdate = errors.New("person is being modified concurrently")
+	// ErrBulkAborted is reported for items of an atomic bulk request that weren't applied because of other items.
+	ErrBulkAborted = errors.New("not applied because another item has failed")
+)
@@ -0,0 +1,57 @@
+package app
+
+// DuplicatePolicy tells what an import does with a person whose email is already in use.
+type DuplicatePolicy string
+
+const (
+	DuplicateSkip   DuplicatePolicy = "skip"
+	DuplicateUpdate DuplicatePolicy = "update"
+	DuplicateFail   DuplicatePolicy = "fail"
+)
+
+func (p DuplicatePolicy) Valid() bool {
+	switch p {
+	case DuplicateSkip, DuplicateUpdate, DuplicateFail:
+		return true
+	}
+
+	return false
+}
+
+type ImportOptions struct {
+	OnDuplicate DuplicatePolicy
+	// DryRun reports what an import would do without changing anything.
+	DryRun bool
+	// Batch is shared by the rows of a dry run, so that a row is reported as a duplicate of the person
+	// an earlier row would have created. Rows aren't related if it is nil.
+	Batch *ImportBatch
+}
+
+// ImportBatch remembers the persons a dry run would have created by their emails.
+// It isn't safe for concurrent use, the rows of an import are imported one by one.
+type ImportBatch struct {
+	created map[string]Person
+}
+
+func NewImportBatch() *ImportBatch {
+	return &ImportBatch{created: make(map[string]Person)}
+}
+
+// Created returns the person an earlier row would have created with the email.
+func (b *ImportBatch) Created(email string) (Person, bool) {
+	per, ok := b.created[email]
+
+	return per, ok
+}
+
+func (b *ImportBatch) AddCreated(per Person) {
+	b.created[per.Email] = per
+}
+
+type ImportOutcome string
+
+const (
+	ImportCreated ImportOutcome = "created"
+	ImportUpdated ImportOutcome = "updated"
+	ImportSkipped ImportOutcome = "skipped"
+)
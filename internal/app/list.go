@@ -0,0 +1,128 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type SortField string
+
+const (
+	SortByID        SortField = "id"
+	SortByEmail     SortField = "email"
+	SortByFirstName SortField = "first_name"
+	SortByLastName  SortField = "last_name"
+	SortByCreatedAt SortField = "created_at"
+)
+
+func (f SortField) Valid() bool {
+	switch f {
+	case SortByID, SortByEmail, SortByFirstName, SortByLastName, SortByCreatedAt:
+		return true
+	}
+
+	return false
+}
+
+// ValueOf returns the value of the sort field of a person as it is stored in a cursor.
+// Sorting by ID needs no value, the cursor always holds the ID.
+func (f SortField) ValueOf(p *Person) string {
+	switch f {
+	case SortByEmail:
+		return p.Email
+	case SortByFirstName:
+		return p.FirstName
+	case SortByLastName:
+		return p.LastName
+	case SortByCreatedAt:
+		return p.CreatedAt.Format(time.RFC3339Nano)
+	default:
+		return ""
+	}
+}
+
+type Sort struct {
+	Field SortField
+	Desc  bool
+}
+
+// Key identifies the sort order a cursor was issued for.
+// It is empty for the default order by ID ascending.
+func (s Sort) Key() string {
+	if s.Field == SortByID && !s.Desc {
+		return ""
+	}
+
+	if s.Desc {
+		return string(s.Field) + ":desc"
+	}
+
+	return string(s.Field) + ":asc"
+}
+
+type PersonFilter struct {
+	Email         string
+	PhonePrefix   string
+	FirstName     string
+	LastName      string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// IncludeDeleted lists soft deleted persons along with live ones.
+	IncludeDeleted bool
+}
+
+type ListRequest struct {
+	Filter PersonFilter
+	Sort   Sort
+	Cursor string
+	Limit  int
+}
+
+// ListQuery is a page of the person list as requested from the repository.
+type ListQuery struct {
+	Filter PersonFilter
+	Sort   Sort
+	After  *Cursor
+	Limit  int
+}
+
+type PersonPage struct {
+	Persons    []Person `json:"persons"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// Cursor points at the last person of a page. It is handed to clients
+// as an opaque string, so its content can change without breaking them.
+type Cursor struct {
+	ID    int    `json:"id"`
+	Sort  string `json:"s,omitempty"`
+	Value string `json:"v,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+
+	return c, nil
+}
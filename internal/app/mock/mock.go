@@ -7,6 +7,7 @@ package mock_app
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	app "github.com/EgorMamoshkin/person-api-crud/internal/app"
 	gomock "github.com/golang/mock/gomock"
@@ -36,47 +37,152 @@ func (m *MockPersonLogic) EXPECT() *MockPersonLogicMockRecorder {
 }
 
 // DeletePerson mocks base method.
-func (m *MockPersonLogic) DeletePerson(ctx context.Context, id int) error {
+func (m *MockPersonLogic) DeletePerson(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePerson", ctx, id)
+	ret := m.ctrl.Call(m, "DeletePerson", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeletePerson indicates an expected call of DeletePerson.
-func (mr *MockPersonLogicMockRecorder) DeletePerson(ctx, id interface{}) *gomock.Call {
+func (mr *MockPersonLogicMockRecorder) DeletePerson(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePerson", reflect.TypeOf((*MockPersonLogic)(nil).DeletePerson), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePerson", reflect.TypeOf((*MockPersonLogic)(nil).DeletePerson), ctx, id, version)
+}
+
+// DeletePersons mocks base method.
+func (m *MockPersonLogic) DeletePersons(ctx context.Context, refs []app.PersonRef, mode app.BulkMode) ([]app.BulkResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePersons", ctx, refs, mode)
+	ret0, _ := ret[0].([]app.BulkResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePersons indicates an expected call of DeletePersons.
+func (mr *MockPersonLogicMockRecorder) DeletePersons(ctx, refs, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePersons", reflect.TypeOf((*MockPersonLogic)(nil).DeletePersons), ctx, refs, mode)
+}
+
+// GetPersonAsOf mocks base method.
+func (m *MockPersonLogic) GetPersonAsOf(ctx context.Context, id int, t time.Time) (*app.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonAsOf", ctx, id, t)
+	ret0, _ := ret[0].(*app.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonAsOf indicates an expected call of GetPersonAsOf.
+func (mr *MockPersonLogicMockRecorder) GetPersonAsOf(ctx, id, t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonAsOf", reflect.TypeOf((*MockPersonLogic)(nil).GetPersonAsOf), ctx, id, t)
 }
 
 // GetPersonByID mocks base method.
-func (m *MockPersonLogic) GetPersonByID(ctx context.Context, id int) (*app.Person, error) {
+func (m *MockPersonLogic) GetPersonByID(ctx context.Context, id int, includeDeleted bool) (*app.Person, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPersonByID", ctx, id)
+	ret := m.ctrl.Call(m, "GetPersonByID", ctx, id, includeDeleted)
 	ret0, _ := ret[0].(*app.Person)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPersonByID indicates an expected call of GetPersonByID.
-func (mr *MockPersonLogicMockRecorder) GetPersonByID(ctx, id interface{}) *gomock.Call {
+func (mr *MockPersonLogicMockRecorder) GetPersonByID(ctx, id, includeDeleted interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonByID", reflect.TypeOf((*MockPersonLogic)(nil).GetPersonByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonByID", reflect.TypeOf((*MockPersonLogic)(nil).GetPersonByID), ctx, id, includeDeleted)
+}
+
+// GetPersonHistory mocks base method.
+func (m *MockPersonLogic) GetPersonHistory(ctx context.Context, id int) ([]app.AuditRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonHistory", ctx, id)
+	ret0, _ := ret[0].([]app.AuditRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonHistory indicates an expected call of GetPersonHistory.
+func (mr *MockPersonLogicMockRecorder) GetPersonHistory(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonHistory", reflect.TypeOf((*MockPersonLogic)(nil).GetPersonHistory), ctx, id)
 }
 
 // GetPersonList mocks base method.
-func (m *MockPersonLogic) GetPersonList(ctx context.Context, offsetId, batchSize int) ([]app.Person, error) {
+func (m *MockPersonLogic) GetPersonList(ctx context.Context, req app.ListRequest) (*app.PersonPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPersonList", ctx, offsetId, batchSize)
-	ret0, _ := ret[0].([]app.Person)
+	ret := m.ctrl.Call(m, "GetPersonList", ctx, req)
+	ret0, _ := ret[0].(*app.PersonPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPersonList indicates an expected call of GetPersonList.
-func (mr *MockPersonLogicMockRecorder) GetPersonList(ctx, offsetId, batchSize interface{}) *gomock.Call {
+func (mr *MockPersonLogicMockRecorder) GetPersonList(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonList", reflect.TypeOf((*MockPersonLogic)(nil).GetPersonList), ctx, req)
+}
+
+// ImportPerson mocks base method.
+func (m *MockPersonLogic) ImportPerson(ctx context.Context, per *app.Person, opts app.ImportOptions) (app.ImportOutcome, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportPerson", ctx, per, opts)
+	ret0, _ := ret[0].(app.ImportOutcome)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportPerson indicates an expected call of ImportPerson.
+func (mr *MockPersonLogicMockRecorder) ImportPerson(ctx, per, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportPerson", reflect.TypeOf((*MockPersonLogic)(nil).ImportPerson), ctx, per, opts)
+}
+
+// PatchPerson mocks base method.
+func (m *MockPersonLogic) PatchPerson(ctx context.Context, id, version int, patch app.PersonPatch) (*app.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchPerson", ctx, id, version, patch)
+	ret0, _ := ret[0].(*app.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchPerson indicates an expected call of PatchPerson.
+func (mr *MockPersonLogicMockRecorder) PatchPerson(ctx, id, version, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchPerson", reflect.TypeOf((*MockPersonLogic)(nil).PatchPerson), ctx, id, version, patch)
+}
+
+// RestorePerson mocks base method.
+func (m *MockPersonLogic) RestorePerson(ctx context.Context, id, version int) (*app.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestorePerson", ctx, id, version)
+	ret0, _ := ret[0].(*app.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestorePerson indicates an expected call of RestorePerson.
+func (mr *MockPersonLogicMockRecorder) RestorePerson(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePerson", reflect.TypeOf((*MockPersonLogic)(nil).RestorePerson), ctx, id, version)
+}
+
+// SearchPersons mocks base method.
+func (m *MockPersonLogic) SearchPersons(ctx context.Context, query string, limit int) ([]app.PersonMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPersons", ctx, query, limit)
+	ret0, _ := ret[0].([]app.PersonMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchPersons indicates an expected call of SearchPersons.
+func (mr *MockPersonLogicMockRecorder) SearchPersons(ctx, query, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonList", reflect.TypeOf((*MockPersonLogic)(nil).GetPersonList), ctx, offsetId, batchSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPersons", reflect.TypeOf((*MockPersonLogic)(nil).SearchPersons), ctx, query, limit)
 }
 
 // StorePerson mocks base method.
@@ -93,6 +199,21 @@ func (mr *MockPersonLogicMockRecorder) StorePerson(ctx, per interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePerson", reflect.TypeOf((*MockPersonLogic)(nil).StorePerson), ctx, per)
 }
 
+// StorePersons mocks base method.
+func (m *MockPersonLogic) StorePersons(ctx context.Context, persons []app.Person, mode app.BulkMode) ([]app.BulkResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorePersons", ctx, persons, mode)
+	ret0, _ := ret[0].([]app.BulkResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StorePersons indicates an expected call of StorePersons.
+func (mr *MockPersonLogicMockRecorder) StorePersons(ctx, persons, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePersons", reflect.TypeOf((*MockPersonLogic)(nil).StorePersons), ctx, persons, mode)
+}
+
 // UpdatePerson mocks base method.
 func (m *MockPersonLogic) UpdatePerson(ctx context.Context, per *app.Person) error {
 	m.ctrl.T.Helper()
@@ -107,6 +228,36 @@ func (mr *MockPersonLogicMockRecorder) UpdatePerson(ctx, per interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePerson", reflect.TypeOf((*MockPersonLogic)(nil).UpdatePerson), ctx, per)
 }
 
+// UpdatePersons mocks base method.
+func (m *MockPersonLogic) UpdatePersons(ctx context.Context, persons []app.Person, mode app.BulkMode) ([]app.BulkResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePersons", ctx, persons, mode)
+	ret0, _ := ret[0].([]app.BulkResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePersons indicates an expected call of UpdatePersons.
+func (mr *MockPersonLogicMockRecorder) UpdatePersons(ctx, persons, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePersons", reflect.TypeOf((*MockPersonLogic)(nil).UpdatePersons), ctx, persons, mode)
+}
+
+// Upsert mocks base method.
+func (m *MockPersonLogic) Upsert(ctx context.Context, per *app.Person) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, per)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockPersonLogicMockRecorder) Upsert(ctx, per interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockPersonLogic)(nil).Upsert), ctx, per)
+}
+
 // MockPersonRepository is a mock of PersonRepository interface.
 type MockPersonRepository struct {
 	ctrl     *gomock.Controller
@@ -130,18 +281,51 @@ func (m *MockPersonRepository) EXPECT() *MockPersonRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddAudit mocks base method.
+func (m *MockPersonRepository) AddAudit(ctx context.Context, recs ...*app.AuditRecord) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range recs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AddAudit", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAudit indicates an expected call of AddAudit.
+func (mr *MockPersonRepositoryMockRecorder) AddAudit(ctx interface{}, recs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, recs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAudit", reflect.TypeOf((*MockPersonRepository)(nil).AddAudit), varargs...)
+}
+
 // Delete mocks base method.
-func (m *MockPersonRepository) Delete(ctx context.Context, id int) error {
+func (m *MockPersonRepository) Delete(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockPersonRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+func (mr *MockPersonRepositoryMockRecorder) Delete(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPersonRepository)(nil).Delete), ctx, id, version)
+}
+
+// DeleteBatch mocks base method.
+func (m *MockPersonRepository) DeleteBatch(ctx context.Context, refs []app.PersonRef) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBatch", ctx, refs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBatch indicates an expected call of DeleteBatch.
+func (mr *MockPersonRepositoryMockRecorder) DeleteBatch(ctx, refs interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPersonRepository)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBatch", reflect.TypeOf((*MockPersonRepository)(nil).DeleteBatch), ctx, refs)
 }
 
 // GetByEmail mocks base method.
@@ -159,34 +343,139 @@ func (mr *MockPersonRepositoryMockRecorder) GetByEmail(ctx, email, id interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockPersonRepository)(nil).GetByEmail), ctx, email, id)
 }
 
+// GetByEmailForUpdate mocks base method.
+func (m *MockPersonRepository) GetByEmailForUpdate(ctx context.Context, email string) (*app.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByEmailForUpdate", ctx, email)
+	ret0, _ := ret[0].(*app.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByEmailForUpdate indicates an expected call of GetByEmailForUpdate.
+func (mr *MockPersonRepositoryMockRecorder) GetByEmailForUpdate(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmailForUpdate", reflect.TypeOf((*MockPersonRepository)(nil).GetByEmailForUpdate), ctx, email)
+}
+
 // GetByID mocks base method.
-func (m *MockPersonRepository) GetByID(ctx context.Context, id int) (*app.Person, error) {
+func (m *MockPersonRepository) GetByID(ctx context.Context, id int, includeDeleted bool) (*app.Person, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret := m.ctrl.Call(m, "GetByID", ctx, id, includeDeleted)
 	ret0, _ := ret[0].(*app.Person)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByID indicates an expected call of GetByID.
-func (mr *MockPersonRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+func (mr *MockPersonRepositoryMockRecorder) GetByID(ctx, id, includeDeleted interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPersonRepository)(nil).GetByID), ctx, id, includeDeleted)
+}
+
+// GetByIDs mocks base method.
+func (m *MockPersonRepository) GetByIDs(ctx context.Context, ids []int, includeDeleted bool) ([]app.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids, includeDeleted)
+	ret0, _ := ret[0].([]app.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockPersonRepositoryMockRecorder) GetByIDs(ctx, ids, includeDeleted interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPersonRepository)(nil).GetByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockPersonRepository)(nil).GetByIDs), ctx, ids, includeDeleted)
+}
+
+// GetHistory mocks base method.
+func (m *MockPersonRepository) GetHistory(ctx context.Context, personID int) ([]app.AuditRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, personID)
+	ret0, _ := ret[0].([]app.AuditRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockPersonRepositoryMockRecorder) GetHistory(ctx, personID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockPersonRepository)(nil).GetHistory), ctx, personID)
 }
 
 // GetPersonList mocks base method.
-func (m *MockPersonRepository) GetPersonList(ctx context.Context, id, batchSize int) ([]app.Person, error) {
+func (m *MockPersonRepository) GetPersonList(ctx context.Context, query app.ListQuery) ([]app.Person, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPersonList", ctx, id, batchSize)
+	ret := m.ctrl.Call(m, "GetPersonList", ctx, query)
 	ret0, _ := ret[0].([]app.Person)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPersonList indicates an expected call of GetPersonList.
-func (mr *MockPersonRepositoryMockRecorder) GetPersonList(ctx, id, batchSize interface{}) *gomock.Call {
+func (mr *MockPersonRepositoryMockRecorder) GetPersonList(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonList", reflect.TypeOf((*MockPersonRepository)(nil).GetPersonList), ctx, query)
+}
+
+// Patch mocks base method.
+func (m *MockPersonRepository) Patch(ctx context.Context, id, version int, patch app.PersonPatch) (*app.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, version, patch)
+	ret0, _ := ret[0].(*app.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockPersonRepositoryMockRecorder) Patch(ctx, id, version, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockPersonRepository)(nil).Patch), ctx, id, version, patch)
+}
+
+// Purge mocks base method.
+func (m *MockPersonRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, deletedBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockPersonRepositoryMockRecorder) Purge(ctx, deletedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockPersonRepository)(nil).Purge), ctx, deletedBefore)
+}
+
+// Restore mocks base method.
+func (m *MockPersonRepository) Restore(ctx context.Context, id, version int) (*app.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id, version)
+	ret0, _ := ret[0].(*app.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockPersonRepositoryMockRecorder) Restore(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockPersonRepository)(nil).Restore), ctx, id, version)
+}
+
+// Search mocks base method.
+func (m *MockPersonRepository) Search(ctx context.Context, query string, limit int) ([]app.PersonMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, limit)
+	ret0, _ := ret[0].([]app.PersonMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockPersonRepositoryMockRecorder) Search(ctx, query, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonList", reflect.TypeOf((*MockPersonRepository)(nil).GetPersonList), ctx, id, batchSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockPersonRepository)(nil).Search), ctx, query, limit)
 }
 
 // Store mocks base method.
@@ -203,6 +492,20 @@ func (mr *MockPersonRepositoryMockRecorder) Store(ctx, person interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockPersonRepository)(nil).Store), ctx, person)
 }
 
+// StoreBatch mocks base method.
+func (m *MockPersonRepository) StoreBatch(ctx context.Context, persons []app.Person) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreBatch", ctx, persons)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreBatch indicates an expected call of StoreBatch.
+func (mr *MockPersonRepositoryMockRecorder) StoreBatch(ctx, persons interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreBatch", reflect.TypeOf((*MockPersonRepository)(nil).StoreBatch), ctx, persons)
+}
+
 // Update mocks base method.
 func (m *MockPersonRepository) Update(ctx context.Context, person *app.Person) error {
 	m.ctrl.T.Helper()
@@ -216,3 +519,83 @@ func (mr *MockPersonRepositoryMockRecorder) Update(ctx, person interface{}) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPersonRepository)(nil).Update), ctx, person)
 }
+
+// UpdateBatch mocks base method.
+func (m *MockPersonRepository) UpdateBatch(ctx context.Context, persons []app.Person) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBatch", ctx, persons)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBatch indicates an expected call of UpdateBatch.
+func (mr *MockPersonRepositoryMockRecorder) UpdateBatch(ctx, persons interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBatch", reflect.TypeOf((*MockPersonRepository)(nil).UpdateBatch), ctx, persons)
+}
+
+// Upsert mocks base method.
+func (m *MockPersonRepository) Upsert(ctx context.Context, person *app.Person) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, person)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockPersonRepositoryMockRecorder) Upsert(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockPersonRepository)(nil).Upsert), ctx, person)
+}
+
+// WithinTx mocks base method.
+func (m *MockPersonRepository) WithinTx(ctx context.Context, fn func(app.PersonRepository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockPersonRepositoryMockRecorder) WithinTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*MockPersonRepository)(nil).WithinTx), ctx, fn)
+}
+
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// WithinTx mocks base method.
+func (m *MockUnitOfWork) WithinTx(ctx context.Context, fn func(app.PersonRepository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockUnitOfWorkMockRecorder) WithinTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*MockUnitOfWork)(nil).WithinTx), ctx, fn)
+}
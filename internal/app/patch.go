@@ -0,0 +1,54 @@
+package app
+
+// PersonPatch holds the person fields to change, nil fields are left as they are.
+type PersonPatch struct {
+	Email     *string
+	Phone     *string
+	FirstName *string
+	LastName  *string
+}
+
+func (p PersonPatch) IsEmpty() bool {
+	return len(p.Fields()) == 0
+}
+
+// Fields returns the names of the Person fields the patch changes.
+func (p PersonPatch) Fields() []string {
+	fields := make([]string, 0, 4)
+
+	if p.Email != nil {
+		fields = append(fields, "Email")
+	}
+
+	if p.Phone != nil {
+		fields = append(fields, "Phone")
+	}
+
+	if p.FirstName != nil {
+		fields = append(fields, "FirstName")
+	}
+
+	if p.LastName != nil {
+		fields = append(fields, "LastName")
+	}
+
+	return fields
+}
+
+func (p PersonPatch) Apply(per *Person) {
+	if p.Email != nil {
+		per.Email = *p.Email
+	}
+
+	if p.Phone != nil {
+		per.Phone = *p.Phone
+	}
+
+	if p.FirstName != nil {
+		per.FirstName = *p.FirstName
+	}
+
+	if p.LastName != nil {
+		per.LastName = *p.LastName
+	}
+}
@@ -2,29 +2,77 @@ package app
 
 import (
 	"context"
+	"time"
 )
 
 type Person struct {
-	Id        int    `json:"id"`
-	Email     string `json:"email" validate:"required"`
-	Phone     string `json:"phone" validate:"required"`
-	FirstName string `json:"firstName" validate:"required"`
-	LastName  string `json:"lastName" validate:"required"`
+	Id        int       `json:"id"`
+	Email     string    `json:"email" validate:"required,email,max=254,not_disposable_email"`
+	Phone     string    `json:"phone" validate:"required,phone"`
+	FirstName string    `json:"firstName" validate:"required,person_name"`
+	LastName  string    `json:"lastName" validate:"required,person_name"`
+	CreatedAt time.Time `json:"createdAt"`
+	Version   int       `json:"version"`
+	// DeletedAt is set while the person is soft deleted and can still be restored.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+type PersonMatch struct {
+	Person
+	Score float64 `json:"score"`
 }
 
 type PersonLogic interface {
 	StorePerson(ctx context.Context, per *Person) error
-	DeletePerson(ctx context.Context, id int) error
-	GetPersonByID(ctx context.Context, id int) (*Person, error)
+	DeletePerson(ctx context.Context, id int, version int) error
+	GetPersonByID(ctx context.Context, id int, includeDeleted bool) (*Person, error)
 	UpdatePerson(ctx context.Context, per *Person) error
-	GetPersonList(ctx context.Context, offsetId int, batchSize int) ([]Person, error)
+	PatchPerson(ctx context.Context, id int, version int, patch PersonPatch) (*Person, error)
+	GetPersonList(ctx context.Context, req ListRequest) (*PersonPage, error)
+	SearchPersons(ctx context.Context, query string, limit int) ([]PersonMatch, error)
+	RestorePerson(ctx context.Context, id int, version int) (*Person, error)
+	GetPersonHistory(ctx context.Context, id int) ([]AuditRecord, error)
+	GetPersonAsOf(ctx context.Context, id int, t time.Time) (*Person, error)
+	StorePersons(ctx context.Context, persons []Person, mode BulkMode) ([]BulkResult, error)
+	UpdatePersons(ctx context.Context, persons []Person, mode BulkMode) ([]BulkResult, error)
+	DeletePersons(ctx context.Context, refs []PersonRef, mode BulkMode) ([]BulkResult, error)
+	ImportPerson(ctx context.Context, per *Person, opts ImportOptions) (ImportOutcome, error)
+	Upsert(ctx context.Context, per *Person) (bool, error)
 }
 
 type PersonRepository interface {
 	Store(ctx context.Context, person *Person) error
-	Delete(ctx context.Context, id int) error
-	GetByID(ctx context.Context, id int) (*Person, error)
+	Delete(ctx context.Context, id int, version int) error
+	GetByID(ctx context.Context, id int, includeDeleted bool) (*Person, error)
+	GetByIDs(ctx context.Context, ids []int, includeDeleted bool) ([]Person, error)
 	GetByEmail(ctx context.Context, email string, id int) (*Person, error)
+	// GetByEmailForUpdate returns the live person with the email, or an empty one, and keeps it from being
+	// changed by others until the end of the transaction.
+	GetByEmailForUpdate(ctx context.Context, email string) (*Person, error)
 	Update(ctx context.Context, person *Person) error
-	GetPersonList(ctx context.Context, id int, batchSize int) ([]Person, error)
+	// Upsert stores the person or updates the one with the same email, it tells whether the person was created.
+	Upsert(ctx context.Context, person *Person) (bool, error)
+	Patch(ctx context.Context, id int, version int, patch PersonPatch) (*Person, error)
+	GetPersonList(ctx context.Context, query ListQuery) ([]Person, error)
+	Search(ctx context.Context, query string, limit int) ([]PersonMatch, error)
+	Restore(ctx context.Context, id int, version int) (*Person, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// StoreBatch, UpdateBatch and DeleteBatch are the batched Store, Update and Delete.
+	// They report failures caused by a single item with *BatchItemError.
+	StoreBatch(ctx context.Context, persons []Person) error
+	UpdateBatch(ctx context.Context, persons []Person) error
+	DeleteBatch(ctx context.Context, refs []PersonRef) error
+	AddAudit(ctx context.Context, recs ...*AuditRecord) error
+	// GetHistory returns audit records of the person ordered from the oldest one.
+	GetHistory(ctx context.Context, personID int) ([]AuditRecord, error)
+	UnitOfWork
+}
+
+// UnitOfWork composes repository calls into a transaction.
+type UnitOfWork interface {
+	// WithinTx runs fn with a repository whose calls are made in a single transaction.
+	// The transaction is committed when fn succeeds and rolled back otherwise.
+	// Transactions conflicting with concurrent ones are retried, so fn may be run several times
+	// and must not change anything but through repo before it succeeds.
+	WithinTx(ctx context.Context, fn func(repo PersonRepository) error) error
 }
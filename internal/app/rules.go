@@ -0,0 +1,135 @@
+package app
+
+import (
+	"fmt"
+	"github.com/nyaruka/phonenumbers"
+	"net/mail"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxEmailLength = 254
+	MaxNameLength  = 100
+)
+
+// RuleError tells which rule a value breaks, Rule is named as the validate tag checking it.
+// The message is meant to follow the name of the field.
+type RuleError struct {
+	Rule    string
+	Message string
+}
+
+func (e *RuleError) Error() string {
+	return e.Message
+}
+
+var errRequired = &RuleError{Rule: "required", Message: "is required"}
+
+// NormalizeEmail lowercases the whole address, which makes emails unique regardless of case.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if email == "" {
+		return "", errRequired
+	}
+
+	if len(email) > MaxEmailLength {
+		return "", &RuleError{Rule: "max", Message: fmt.Sprintf("must be at most %d characters long", MaxEmailLength)}
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		return "", &RuleError{Rule: "email", Message: "must be a valid email address"}
+	}
+
+	if IsDisposableEmail(email) {
+		return "", &RuleError{Rule: "not_disposable_email", Message: "must not be a disposable email address"}
+	}
+
+	return email, nil
+}
+
+// NormalizePhone formats the phone number in E.164, numbers without a country code are taken
+// as the ones of the region, none are accepted if it is empty.
+func NormalizePhone(phone, region string) (string, error) {
+	phone = strings.TrimSpace(phone)
+
+	if phone == "" {
+		return "", errRequired
+	}
+
+	num, err := phonenumbers.Parse(phone, region)
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		if region == "" {
+			return "", &RuleError{Rule: "phone", Message: "must be a valid phone number with a country code"}
+		}
+
+		return "", &RuleError{Rule: "phone", Message: "must be a valid phone number"}
+	}
+
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
+
+// NormalizeName trims the name and collapses runs of whitespace in it.
+func NormalizeName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+
+	switch {
+	case name == "":
+		return "", errRequired
+	case utf8.RuneCountInString(name) > MaxNameLength:
+		return "", &RuleError{Rule: "max", Message: fmt.Sprintf("must be at most %d characters long", MaxNameLength)}
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return "", &RuleError{Rule: "person_name", Message: "must not contain control characters"}
+	}
+
+	return name, nil
+}
+
+// IsDisposableEmail tells whether the email belongs to a throwaway mailbox service, subdomains included.
+func IsDisposableEmail(email string) bool {
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+
+	for domain != "" {
+		if disposableDomains[domain] {
+			return true
+		}
+
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			return false
+		}
+
+		domain = parent
+	}
+
+	return false
+}
+
+// disposableDomains are the domains of widespread throwaway mailbox services.
+var disposableDomains = map[string]bool{
+	"10minutemail.com":       true,
+	"burnermail.io":          true,
+	"discard.email":          true,
+	"dispostable.com":        true,
+	"emailondeck.com":        true,
+	"fakeinbox.com":          true,
+	"getnada.com":            true,
+	"guerrillamail.com":      true,
+	"guerrillamail.net":      true,
+	"guerrillamailblock.com": true,
+	"maildrop.cc":            true,
+	"mailinator.com":         true,
+	"mailnesia.com":          true,
+	"mintemail.com":          true,
+	"mohmal.com":             true,
+	"sharklasers.com":        true,
+	"spamgourmet.com":        true,
+	"temp-mail.org":          true,
+	"tempmail.com":           true,
+	"throwawaymail.com":      true,
+	"trashmail.com":          true,
+	"yopmail.com":            true,
+}
@@ -0,0 +1,25 @@
+package app
+
+// IsolationLevel of the transactions run by UnitOfWork.WithinTx.
+type IsolationLevel string
+
+const (
+	ReadCommitted  IsolationLevel = "read_committed"
+	RepeatableRead IsolationLevel = "repeatable_read"
+	Serializable   IsolationLevel = "serializable"
+)
+
+func (l IsolationLevel) Valid() bool {
+	switch l {
+	case ReadCommitted, RepeatableRead, Serializable:
+		return true
+	}
+
+	return false
+}
+
+type TxOptions struct {
+	Isolation IsolationLevel
+	// MaxRetries is how many times a transaction that failed to serialize with concurrent ones is run again.
+	MaxRetries int
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const redacted = "[redacted]"
+
+// Print writes the configuration in YAML, which the service can read back but for the secrets redacted.
+func Print(w io.Writer, cfg *Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(dump(reflect.ValueOf(*cfg))); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// dump maps the fields of a config struct by their keys, durations are formatted as they are written in configs.
+func dump(v reflect.Value) map[string]interface{} {
+	t := v.Type()
+	m := make(map[string]interface{}, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		key := field.Tag.Get("mapstructure")
+
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			m[key] = dump(value)
+		} else {
+			m[key] = printable(field, value)
+		}
+	}
+
+	return m
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// printable formats the value of a setting to be printed or logged.
+func printable(field reflect.StructField, value reflect.Value) interface{} {
+	switch {
+	case field.Tag.Get("secret") == "true" && !value.IsZero():
+		return redacted
+	case field.Type == durationType:
+		return time.Duration(value.Int()).String()
+	default:
+		return value.Interface()
+	}
+}
+
+// lookupField finds the field of the setting with the key in the config struct.
+func lookupField(v reflect.Value, key string) (reflect.StructField, reflect.Value) {
+	name, rest, nested := strings.Cut(key, ".")
+
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); field.Tag.Get("mapstructure") == name {
+			if nested {
+				return lookupField(v.Field(i), rest)
+			}
+
+			return field, v.Field(i)
+		}
+	}
+
+	panic("config has no field for setting " + key)
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// Watcher reloads the configuration and applies its reloadable settings, changes of the other settings
+// are only logged as they take a restart.
+type Watcher struct {
+	args  []string
+	apply func(*Config)
+
+	mu sync.Mutex
+	// current is the configuration in effect: the loaded one with the reloadable settings reloaded.
+	current Config
+	// loaded is the configuration loaded last, changes of the settings which take a restart are
+	// told from it so that they are warned about once.
+	loaded Config
+}
+
+// NewWatcher makes a watcher of the configuration loaded from args, apply is called with the configuration
+// every time some of the reloadable settings change.
+func NewWatcher(args []string, cfg *Config, apply func(*Config)) *Watcher {
+	return &Watcher{args: args, apply: apply, current: *cfg, loaded: *cfg}
+}
+
+// Reload loads the configuration again. It is applied only if it is valid, the current one is kept otherwise.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	loaded, _, err := Load(w.args)
+	if err != nil {
+		return err
+	}
+
+	next := w.current
+	changed := false
+
+	for _, s := range settings {
+		field, value := lookupField(reflect.ValueOf(&next).Elem(), s.key)
+		_, newValue := lookupField(reflect.ValueOf(loaded).Elem(), s.key)
+
+		if reflect.DeepEqual(value.Interface(), newValue.Interface()) {
+			continue
+		}
+
+		_, lastValue := lookupField(reflect.ValueOf(&w.loaded).Elem(), s.key)
+
+		if !s.reloadable && reflect.DeepEqual(lastValue.Interface(), newValue.Interface()) {
+			continue
+		}
+
+		entry := logrus.WithFields(logrus.Fields{
+			"key": s.key,
+			"old": printable(field, value),
+			"new": printable(field, newValue),
+		})
+
+		if !s.reloadable {
+			entry.Warn("configuration change takes a restart")
+
+			continue
+		}
+
+		value.Set(newValue)
+		changed = true
+
+		entry.Info("configuration changed")
+	}
+
+	w.loaded = *loaded
+
+	if changed {
+		w.current = next
+		w.apply(&next)
+	}
+
+	return nil
+}
+
+// Run reloads the configuration when its file changes and on SIGHUP until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Editors may write a file several times in a row, the writes made during a reload are handled at once.
+	writes := make(chan struct{}, 1)
+
+	v, _, err := newViper(w.args)
+	if err != nil {
+		logrus.Errorf("can't watch config file: %s", err)
+	} else if v.ConfigFileUsed() != "" {
+		v.OnConfigChange(func(fsnotify.Event) {
+			select {
+			case writes <- struct{}{}:
+			default:
+			}
+		})
+		v.WatchConfig()
+	}
+
+	for {
+		var trigger string
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			trigger = "SIGHUP"
+		case <-writes:
+			trigger = "file change"
+		}
+
+		logrus.WithField("trigger", trigger).Info("reloading configuration")
+
+		if err := w.Reload(); err != nil {
+			logrus.Errorf("can't reload configuration, keeping the current one: %s", err)
+		}
+	}
+}
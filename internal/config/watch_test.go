@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	setRequired(t)
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("log:\n  level: info\ndb:\n  max_open_conns: 10\n"), 0o600))
+
+	args := []string{"-c", file}
+
+	cfg, _, err := Load(args)
+	require.NoError(t, err)
+
+	var applied []*Config
+
+	watcher := NewWatcher(args, cfg, func(cfg *Config) { applied = append(applied, cfg) })
+
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	require.NoError(t, os.WriteFile(file, []byte("log:\n  level: debug\ndb:\n  max_open_conns: 20\n"), 0o600))
+	require.NoError(t, watcher.Reload())
+
+	require.Len(t, applied, 1)
+	require.Equal(t, "debug", applied[0].Log.Level)
+	require.Equal(t, 10, applied[0].DB.MaxOpenConns, "settings which take a restart must be kept")
+
+	require.Len(t, hook.AllEntries(), 2)
+	require.Equal(t, logrus.Fields{"key": "db.max_open_conns", "old": 10, "new": 20}, hook.AllEntries()[0].Data)
+	require.Equal(t, logrus.WarnLevel, hook.AllEntries()[0].Level)
+	require.Equal(t, logrus.Fields{"key": "log.level", "old": "info", "new": "debug"}, hook.AllEntries()[1].Data)
+	require.Equal(t, logrus.InfoLevel, hook.AllEntries()[1].Level)
+
+	// Nothing is applied if the reloadable settings are the same, and pending restarts aren't warned about again.
+	require.NoError(t, watcher.Reload())
+	require.Len(t, applied, 1)
+	require.Len(t, hook.AllEntries(), 2)
+
+	require.NoError(t, os.WriteFile(file, []byte("log:\n  level: debug\ndb:\n  max_open_conns: 30\n"), 0o600))
+	require.NoError(t, watcher.Reload())
+	require.Len(t, applied, 1)
+	require.Len(t, hook.AllEntries(), 3)
+	require.Equal(t, logrus.Fields{"key": "db.max_open_conns", "old": 10, "new": 30}, hook.LastEntry().Data)
+	require.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+
+	require.NoError(t, os.WriteFile(file, []byte("log:\n  level: loud\n"), 0o600))
+	require.Error(t, watcher.Reload())
+	require.Len(t, applied, 1, "invalid configuration must not be applied")
+}
+
+func TestWatcher_Run(t *testing.T) {
+	setRequired(t)
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("logic:\n  timeout: 1s\n"), 0o600))
+
+	args := []string{"--config=" + file}
+
+	cfg, _, err := Load(args)
+	require.NoError(t, err)
+
+	var (
+		mu      sync.Mutex
+		timeout time.Duration
+	)
+
+	watcher := NewWatcher(args, cfg, func(cfg *Config) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		timeout = cfg.Logic.Timeout
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	// The file is written until the change is seen, since the watching starts in the background.
+	require.Eventually(t, func() bool {
+		require.NoError(t, os.WriteFile(file, []byte("logic:\n  timeout: 2s\n"), 0o600))
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return timeout == 2*time.Second
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Check tells whether a dependency of the service works, the details it returns are reported either way.
+type Check func(ctx context.Context) (details interface{}, err error)
+
+type ComponentStatus struct {
+	Status  Status      `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status     Status                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// Registry runs the checks of the dependencies the service needs to serve requests.
+type Registry struct {
+	mu      sync.RWMutex
+	checks  map[string]Check
+	timeout time.Duration
+	// stopping makes the service not ready regardless of the checks.
+	stopping atomic.Bool
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{checks: make(map[string]Check), timeout: timeout}
+}
+
+// Register adds the check of the named dependency, replacing the one registered with the same name.
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks[name] = check
+}
+
+// SetStopping reports the service as not ready from now on, so that load balancers stop sending
+// requests to it while it is shutting down.
+func (r *Registry) SetStopping() {
+	r.stopping.Store(true)
+}
+
+// Drain reports the service as not ready and waits for the delay, giving load balancers the time to notice it
+// before the server stops accepting requests. It returns early with the error of ctx when ctx is done.
+func (r *Registry) Drain(ctx context.Context, delay time.Duration) error {
+	r.SetStopping()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Ready runs all the checks concurrently, each of them within the timeout of the registry.
+// The service is ready when all of them pass and it isn't stopping.
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]Check, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Components: make(map[string]ComponentStatus, len(checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for name, check := range checks {
+		name, check := name, check
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			status := r.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Components[name] = status
+			if status.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if r.stopping.Load() {
+		report.Status = StatusDown
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, check Check) (status ComponentStatus) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// A panicking check must not take the service down along with it.
+	defer func() {
+		if p := recover(); p != nil {
+			status = ComponentStatus{Status: StatusDown, Error: fmt.Sprintf("check panicked: %v", p)}
+		}
+	}()
+
+	details, err := check(ctx)
+	if err != nil {
+		return ComponentStatus{Status: StatusDown, Error: err.Error(), Details: details}
+	}
+
+	return ComponentStatus{Status: StatusUp, Details: details}
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Ready(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewRegistry(10 * time.Millisecond)
+	require.Equal(t, Report{Status: StatusUp, Components: map[string]ComponentStatus{}}, r.Ready(ctx))
+
+	r.Register("database", func(ctx context.Context) (interface{}, error) {
+		return map[string]int{"open": 1}, nil
+	})
+	require.Equal(t, Report{Status: StatusUp, Components: map[string]ComponentStatus{
+		"database": {Status: StatusUp, Details: map[string]int{"open": 1}},
+	}}, r.Ready(ctx))
+
+	r.Register("cache", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	})
+	r.Register("queue", func(ctx context.Context) (interface{}, error) {
+		panic(errors.New("boom"))
+	})
+	require.Equal(t, Report{Status: StatusDown, Components: map[string]ComponentStatus{
+		"database": {Status: StatusUp, Details: map[string]int{"open": 1}},
+		"cache":    {Status: StatusDown, Error: "context deadline exceeded"},
+		"queue":    {Status: StatusDown, Error: "check panicked: boom"},
+	}}, r.Ready(ctx))
+
+	stopping := NewRegistry(time.Second)
+	stopping.SetStopping()
+	require.Equal(t, StatusDown, stopping.Ready(ctx).Status)
+}
+
+func TestRegistry_Drain(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewRegistry(time.Second)
+	drained := make(chan error, 1)
+
+	start := time.Now()
+
+	go func() {
+		drained <- r.Drain(ctx, 100*time.Millisecond)
+	}()
+
+	require.Eventually(t, func() bool {
+		return r.Ready(ctx).Status == StatusDown
+	}, time.Second, time.Millisecond, "the service must be reported as not ready while draining")
+
+	select {
+	case <-drained:
+		t.Fatal("draining must last for the delay")
+	default:
+	}
+
+	require.NoError(t, <-drained)
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, NewRegistry(time.Second).Drain(ctx, time.Hour), context.DeadlineExceeded)
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/labstack/echo/v4"
+	"net"
+	"net/http"
+)
+
+// HeaderActor carries the name of the user on whose behalf the request is made.
+// It is expected to be set by the authenticating proxy in front of the service.
+const HeaderActor = "X-Actor"
+
+const anonymousActor = "anonymous"
+
+type adminKey struct{}
+
+// Admins tells the admins apart from the other callers. As the actor is only trusted from the authenticating proxy,
+// callers are admins when they are named by a request coming from one of the proxies.
+type Admins struct {
+	// Names are the actors who are admins.
+	Names []string
+	// Proxies are the IPs or CIDRs of the proxies setting HeaderActor. Nobody is an admin while there are none.
+	Proxies []string
+}
+
+// auditContext passes the actor to the audit log through the request context, the request ID is passed by requestID.
+// Requests of the admins are marked as such in the context too.
+func auditContext(admins Admins) echo.MiddlewareFunc {
+	proxies := parseNetworks(admins.Proxies)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			actor := req.Header.Get(HeaderActor)
+			if actor == "" {
+				actor = anonymousActor
+			}
+
+			ctx := app.ContextWithActor(req.Context(), actor)
+
+			if actor != anonymousActor && contains(admins.Names, actor) && fromNetworks(req, proxies) {
+				ctx = context.WithValue(ctx, adminKey{}, true)
+			}
+
+			c.SetRequest(req.WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+func isAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey{}).(bool)
+
+	return admin
+}
+
+// parseNetworks parses IPs and CIDRs, the IPs are taken as networks of a single address.
+// Invalid ones are skipped as the configuration is validated before.
+func parseNetworks(addrs []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(addrs))
+
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(addr); err == nil {
+			networks = append(networks, network)
+		}
+	}
+
+	return networks
+}
+
+// fromNetworks tells whether the request comes directly from one of the networks, forwarding headers aren't trusted.
+func fromNetworks(req *http.Request, networks []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// maxBulkBody limits bulk bodies to about app.MaxBulkSize items, so that larger ones are rejected before being parsed.
+const maxBulkBody = app.MaxBulkSize * (4 << 10)
+
+type BulkResponse struct {
+	Mode      app.BulkMode     `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+type BulkItemResult struct {
+	Index  int         `json:"index"`
+	Status int         `json:"status"`
+	Person *app.Person `json:"person,omitempty"`
+	Error  *Problem    `json:"error,omitempty"`
+}
+
+func (ph *PersonHandler) StorePersons(c echo.Context) error {
+	return writePersons(c, ph.personLogic.StorePersons)
+}
+
+func (ph *PersonHandler) UpdatePersons(c echo.Context) error {
+	return writePersons(c, ph.personLogic.UpdatePersons)
+}
+
+func (ph *PersonHandler) DeletePersons(c echo.Context) error {
+	mode := bulkMode(c)
+
+	var refs []app.PersonRef
+
+	if err := bindBulk(c, &refs); err != nil {
+		return err
+	}
+
+	if err := app.CheckBulk(len(refs), mode); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	results, err := runBulk(mode, make([]error, len(refs)), func(valid []int) ([]app.BulkResult, error) {
+		return ph.personLogic.DeletePersons(ctx, refs, mode)
+	})
+	if err != nil {
+		return err
+	}
+
+	return bulkResponse(c, mode, results)
+}
+
+// writePersons binds and validates the persons of a bulk store or update and writes the valid ones with write.
+func writePersons(
+	c echo.Context,
+	write func(ctx context.Context, persons []app.Person, mode app.BulkMode) ([]app.BulkResult, error),
+) error {
+	mode := bulkMode(c)
+
+	var persons []app.Person
+
+	if err := bindBulk(c, &persons); err != nil {
+		return err
+	}
+
+	if err := app.CheckBulk(len(persons), mode); err != nil {
+		return err
+	}
+
+	invalid := make([]error, len(persons))
+	for i := range persons {
+		invalid[i] = c.Validate(&persons[i])
+	}
+
+	ctx := c.Request().Context()
+
+	results, err := runBulk(mode, invalid, func(valid []int) ([]app.BulkResult, error) {
+		items := make([]app.Person, len(valid))
+		for j, i := range valid {
+			items[j] = persons[i]
+		}
+
+		return write(ctx, items, mode)
+	})
+	if err != nil {
+		return err
+	}
+
+	return bulkResponse(c, mode, results)
+}
+
+// bindBulk binds the items of a bulk request from a body of at most maxBulkBody bytes.
+func bindBulk(c echo.Context, items interface{}) error {
+	limitBody(c, maxBulkBody)
+
+	if err := c.Bind(items); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return readError(err)
+		}
+
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetInternal(err)
+	}
+
+	return nil
+}
+
+func bulkMode(c echo.Context) app.BulkMode {
+	if mode := c.QueryParam("mode"); mode != "" {
+		return app.BulkMode(mode)
+	}
+
+	return app.BulkAtomic
+}
+
+// runBulk passes the items that have no validation errors to apply and puts their results in place.
+// An atomic request isn't applied at all when some of its items are invalid.
+func runBulk(mode app.BulkMode, invalid []error, apply func(valid []int) ([]app.BulkResult, error)) ([]app.BulkResult, error) {
+	results := make([]app.BulkResult, len(invalid))
+	valid := make([]int, 0, len(invalid))
+
+	for i, err := range invalid {
+		if err != nil {
+			results[i].Err = err
+		} else {
+			valid = append(valid, i)
+		}
+	}
+
+	if len(valid) < len(invalid) && mode == app.BulkAtomic {
+		for _, i := range valid {
+			results[i].Err = app.ErrBulkAborted
+		}
+
+		return results, nil
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	applied, err := apply(valid)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, i := range valid {
+		results[i] = applied[j]
+	}
+
+	return results, nil
+}
+
+// bulkResponse responds with 200 when every item has succeeded and with 207 Multi-Status otherwise.
+func bulkResponse(c echo.Context, mode app.BulkMode, results []app.BulkResult) error {
+	resp := BulkResponse{Mode: mode, Results: make([]BulkItemResult, len(results))}
+
+	for i, res := range results {
+		item := BulkItemResult{Index: i, Status: http.StatusOK, Person: res.Person}
+
+		if res.Err != nil {
+			status, body := errorResponse(res.Err)
+			if status >= http.StatusInternalServerError {
+				logging.FromContext(c.Request().Context()).Error(res.Err)
+			}
+
+			prob := problem(status, body)
+			item.Status, item.Error, item.Person = status, &prob, nil
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+
+		resp.Results[i] = item
+	}
+
+	status := http.StatusOK
+	if resp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	return c.JSON(status, resp)
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPersonHandler_StorePersons(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context)
+
+	valid := app.Person{Email: "a@gmail.com", Phone: "+14155550101", FirstName: "Test", LastName: "Test"}
+	stored := app.Person{Id: 1, Email: "a@gmail.com", Phone: "+14155550101", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 1}
+
+	testTable := []struct {
+		name      string
+		query     string
+		inputBody string
+		mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `[{"email":"a@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test"}]`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().StorePersons(ctx, []app.Person{valid}, app.BulkAtomic).Return([]app.BulkResult{{Person: &stored}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"mode":"atomic","succeeded":1,"failed":0,"results":[{"index":0,"status":200,"person":` +
+				`{"id":1,"email":"a@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":1}}]}`,
+		}, {
+			name:               "Atomic Invalid Item",
+			inputBody:          `[{"email":"a@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test"},{"email":"b@gmail.com"}]`,
+			mockBehavior:       func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode: 207,
+			expectedRequestBody: `{"mode":"atomic","succeeded":0,"failed":2,"results":[` +
+				`{"index":0,"status":424,"error":{"type":"about:blank","title":"Failed Dependency","status":424,"detail":"not applied because another item has failed","code":"failed_dependency"}},` +
+				`{"index":1,"status":400,"error":{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: ` +
+				`phone is a required field; firstName is a required field; ` +
+				`lastName is a required field","code":"validation_error",` +
+				`"errors":[{"field":"phone","rule":"required","message":"phone is a required field"},{"field":"firstName","rule":"required","message":"firstName is a required field"},{"field":"lastName","rule":"required","message":"lastName is a required field"}]}}]}`,
+		}, {
+			name:      "Per Item",
+			query:     "?mode=per_item",
+			inputBody: `[{"email":"b@gmail.com"},{"email":"a@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test"}]`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().StorePersons(ctx, []app.Person{valid}, app.BulkPerItem).Return([]app.BulkResult{
+					{Err: fmt.Errorf("%w: another person with email address a@gmail.com already exists", app.ErrEmailConflict)},
+				}, nil)
+			},
+			expectedStatusCode: 207,
+			expectedRequestBody: `{"mode":"per_item","succeeded":0,"failed":2,"results":[` +
+				`{"index":0,"status":400,"error":{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: ` +
+				`phone is a required field; firstName is a required field; ` +
+				`lastName is a required field","code":"validation_error",` +
+				`"errors":[{"field":"phone","rule":"required","message":"phone is a required field"},{"field":"firstName","rule":"required","message":"firstName is a required field"},{"field":"lastName","rule":"required","message":"lastName is a required field"}]}},` +
+				`{"index":1,"status":409,"error":{"type":"about:blank","title":"Conflict","status":409,"detail":"email address is already in use: another person with email address a@gmail.com already exists","code":"email_conflict"}}]}`,
+		}, {
+			name:                "Unknown Mode",
+			query:               "?mode=sometimes",
+			inputBody:           `[{"email":"a@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test"}]`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: bulk mode must be atomic or per_item, got \"sometimes\"","code":"validation_error"}`,
+		}, {
+			name:                "Empty",
+			inputBody:           `[]`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: bulk request has no items","code":"validation_error"}`,
+		}, {
+			name:                "Body Too Large",
+			inputBody:           `[{"email":"` + strings.Repeat("a", maxBulkBody) + `"}]`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  413,
+			expectedRequestBody: `{"error":{"code":"request_entity_too_large","message":"request body must not be larger than 4096000 bytes"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			testCase.mockBehavior(perLog, context.Background())
+
+			hand := PersonHandler{perLog}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/person/bulk"+testCase.query, bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
+			r.POST("/person/bulk", hand.StorePersons)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, testCase.expectedStatusCode)
+			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+}
+
+func TestPersonHandler_DeletePersons(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	perLog := mock_app.NewMockPersonLogic(ctrl)
+	perLog.EXPECT().DeletePersons(context.Background(), []app.PersonRef{{ID: 1, Version: 2}, {ID: 2, Version: 1}}, app.BulkAtomic).
+		Return([]app.BulkResult{{}, {}}, nil)
+
+	hand := PersonHandler{perLog}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("DELETE", "/person/bulk", bytes.NewBufferString(`[{"id":1,"version":2},{"id":2,"version":1}]`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	r := echo.New()
+	r.HTTPErrorHandler = ErrorHandler
+	r.Validator = NewValidator("")
+	r.DELETE("/person/bulk", hand.DeletePersons)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, 200, rec.Code)
+	require.Equal(t, `{"mode":"atomic","succeeded":2,"failed":0,"results":[{"index":0,"status":200},{"index":1,"status":200}]}`,
+		strings.TrimRight(rec.Body.String(), "\n"))
+}
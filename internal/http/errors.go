@@ -0,0 +1,136 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Errors lists the invalid fields of a person, so that clients can tell which of them to fix.
+	Errors []app.FieldError `json:"errors,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details body, validation errors and failed bulk and import items
+// are rendered with it.
+type Problem struct {
+	Type   string           `json:"type"`
+	Title  string           `json:"title"`
+	Status int              `json:"status"`
+	Detail string           `json:"detail"`
+	Code   string           `json:"code"`
+	Errors []app.FieldError `json:"errors,omitempty"`
+}
+
+// ErrorHandler is an echo.HTTPErrorHandler rendering domain errors with their HTTP status codes
+// in a common JSON envelope, but for validation errors, which are rendered as problem details.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, body := errorResponse(err)
+
+	log := logging.FromContext(c.Request().Context())
+
+	if status >= http.StatusInternalServerError {
+		log.Error(err)
+	} else {
+		log.Warn(err)
+	}
+
+	switch {
+	case c.Request().Method == http.MethodHead:
+		err = c.NoContent(status)
+	case errors.Is(err, app.ErrValidation):
+		c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+
+		err = c.JSON(status, problem(status, body))
+	default:
+		err = c.JSON(status, ErrorResponse{Error: body})
+	}
+
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func errorResponse(err error) (int, ErrorBody) {
+	switch {
+	case errors.Is(err, app.ErrNotFound):
+		return http.StatusNotFound, ErrorBody{Code: "not_found", Message: err.Error()}
+	case errors.Is(err, app.ErrEmailConflict):
+		return http.StatusConflict, ErrorBody{Code: "email_conflict", Message: err.Error()}
+	case errors.Is(err, app.ErrValidation):
+		body := ErrorBody{Code: "validation_error", Message: err.Error()}
+
+		var verr *app.ValidationError
+		if errors.As(err, &verr) {
+			body.Errors = verr.Fields
+		}
+
+		return http.StatusBadRequest, body
+	case errors.Is(err, app.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, ErrorBody{Code: "precondition_failed", Message: err.Error()}
+	case errors.Is(err, app.ErrNotDeleted):
+		return http.StatusConflict, ErrorBody{Code: "not_deleted", Message: err.Error()}
+	case errors.Is(err, app.ErrConcurrentUpdate):
+		return http.StatusConflict, ErrorBody{Code: "concurrent_update", Message: err.Error()}
+	case errors.Is(err, app.ErrBulkAborted):
+		return http.StatusFailedDependency, ErrorBody{Code: "failed_dependency", Message: err.Error()}
+	case errors.Is(err, app.ErrTimeout):
+		return http.StatusGatewayTimeout, ErrorBody{Code: "timeout", Message: app.ErrTimeout.Error()}
+	case errors.Is(err, app.ErrUnavailable):
+		return http.StatusServiceUnavailable, ErrorBody{Code: "unavailable", Message: app.ErrUnavailable.Error()}
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code, ErrorBody{Code: statusCode(he.Code), Message: fmt.Sprint(he.Message)}
+	}
+
+	return http.StatusInternalServerError, ErrorBody{Code: "internal_error", Message: "internal server error"}
+}
+
+func problem(status int, body ErrorBody) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: body.Message,
+		Code:   body.Code,
+		Errors: body.Errors,
+	}
+}
+
+// responseStatus is the status of the response to a request handled with err. The error is rendered by the
+// error handler after the middlewares, so its status is the one to be sent unless the response is committed.
+func responseStatus(c echo.Context, err error) int {
+	if err != nil && !c.Response().Committed {
+		status, _ := errorResponse(err)
+
+		return status
+	}
+
+	return c.Response().Status
+}
+
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+func invalidParam(name string, err error) error {
+	return fmt.Errorf("%w: invalid parameter %s: %s", app.ErrValidation, name, err)
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatchVersion returns the person version the client has based its change on.
+// Writes without it could silently overwrite changes of other clients, so it is required.
+func ifMatchVersion(c echo.Context) (int, error) {
+	header := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if header == "" {
+		return 0, echo.NewHTTPError(http.StatusPreconditionRequired, "If-Match header with the person ETag is required")
+	}
+
+	version, err := strconv.Unquote(header)
+	if err == nil {
+		var n int
+
+		n, err = strconv.Atoi(version)
+		if err == nil {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: malformed If-Match header %s", app.ErrValidation, header)
+}
+
+// noneMatch reports whether the If-None-Match header doesn't list the given ETag.
+func noneMatch(c echo.Context, tag string) bool {
+	header := c.Request().Header.Get(HeaderIfNoneMatch)
+	if header == "" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+		if candidate == "*" || candidate == tag {
+			return false
+		}
+	}
+
+	return true
+}
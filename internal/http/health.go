@@ -0,0 +1,34 @@
+package http
+
+import (
+	"github.com/EgorMamoshkin/person-api-crud/internal/health"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+func NewHealthHandler(e *echo.Echo, registry *health.Registry) {
+	handler := &HealthHandler{registry: registry}
+
+	e.GET("/healthz", handler.Live)
+	e.GET("/readyz", handler.Ready)
+}
+
+// Live tells that the process is able to serve requests at all, dependencies aren't checked.
+func (hh *HealthHandler) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, health.Report{Status: health.StatusUp})
+}
+
+// Ready tells whether the service can serve requests, it isn't while a dependency is down or during shutdown.
+func (hh *HealthHandler) Ready(c echo.Context) error {
+	report := hh.registry.Ready(c.Request().Context())
+
+	if report.Status != health.StatusUp {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/EgorMamoshkin/person-api-crud/internal/health"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler(t *testing.T) {
+	testTable := []struct {
+		name                string
+		path                string
+		check               health.Check
+		stopping            bool
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "Live",
+			path: "/healthz",
+			check: func(ctx context.Context) (interface{}, error) {
+				return nil, errors.New("connection refused")
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"up"}`,
+		}, {
+			name: "Ready",
+			path: "/readyz",
+			check: func(ctx context.Context) (interface{}, error) {
+				return map[string]int{"open": 1}, nil
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"up","components":{"database":{"status":"up","details":{"open":1}}}}`,
+		}, {
+			name: "Dependency Down",
+			path: "/readyz",
+			check: func(ctx context.Context) (interface{}, error) {
+				return nil, errors.New("connection refused")
+			},
+			expectedStatusCode:  503,
+			expectedRequestBody: `{"status":"down","components":{"database":{"status":"down","error":"connection refused"}}}`,
+		}, {
+			name: "Stopping",
+			path: "/readyz",
+			check: func(ctx context.Context) (interface{}, error) {
+				return nil, nil
+			},
+			stopping:            true,
+			expectedStatusCode:  503,
+			expectedRequestBody: `{"status":"down","components":{"database":{"status":"up"}}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			registry := health.NewRegistry(time.Second)
+			registry.Register("database", testCase.check)
+
+			if testCase.stopping {
+				registry.SetStopping()
+			}
+
+			rec := httptest.NewRecorder()
+
+			r := echo.New()
+			NewHealthHandler(r, registry)
+
+			r.ServeHTTP(rec, httptest.NewRequest("GET", testCase.path, nil))
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+)
+
+// maxRequestIDLength caps the length of the request IDs taken from clients.
+const maxRequestIDLength = 128
+
+// requestID takes the ID of the request from X-Request-ID or generates one and sends it back in the response.
+// IDs given by clients are only taken when they are short and made of letters, digits, '.', '_' and '-',
+// so that they can't be used to forge or flood log lines.
+// The ID goes to the request context along with the logger of the request, which carries it and the route.
+func requestID() echo.MiddlewareFunc {
+	withID := middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		RequestIDHandler: func(c echo.Context, id string) {
+			req := c.Request()
+
+			fields := logrus.Fields{"request_id": id, "route": c.Path()}
+
+			if span := trace.SpanContextFromContext(req.Context()); span.IsValid() {
+				fields["trace_id"] = span.TraceID().String()
+			}
+
+			ctx := app.ContextWithRequestID(req.Context(), id)
+			ctx = logging.NewContext(ctx, logging.FromContext(ctx).WithFields(fields))
+
+			c.SetRequest(req.WithContext(ctx))
+		},
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		next = withID(next)
+
+		return func(c echo.Context) error {
+			if header := c.Request().Header; !validRequestID(header.Get(echo.HeaderXRequestID)) {
+				header.Del(echo.HeaderXRequestID)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func validRequestID(id string) bool {
+	if len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// accessLog logs every request with the logger of the request once it is handled.
+// Errors are left to the error handler, which logs them at the level of their status.
+func accessLog() echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			logging.FromContext(c.Request().Context()).WithFields(logrus.Fields{
+				"method":     v.Method,
+				"uri":        v.URI,
+				"status":     responseStatus(c, v.Error),
+				"latency_ms": float64(v.Latency.Microseconds()) / 1000,
+				"bytes_in":   v.ContentLength,
+				"bytes_out":  v.ResponseSize,
+			}).Info("request")
+
+			return nil
+		},
+		LogMethod:        true,
+		LogURI:           true,
+		LogStatus:        true,
+		LogLatency:       true,
+		LogContentLength: true,
+		LogResponseSize:  true,
+		LogError:         true,
+	})
+}
+
+// personID parses the ID of the person the request is about and adds it to the logger of the request.
+func personID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, invalidParam("id", err)
+	}
+
+	logging.AddFields(c.Request().Context(), logrus.Fields{"person_id": id})
+
+	return id, nil
+}
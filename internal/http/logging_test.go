@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestLogging(t *testing.T) {
+	testTable := []struct {
+		name               string
+		requestID          string
+		requestIDReplaced  bool
+		mockBehavior       func(s *mock_app.MockPersonLogic)
+		expectedStatusCode int
+		expectedLevel      logrus.Level
+	}{
+		{
+			name:      "Request ID Given",
+			requestID: "req-1",
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonByID(gomock.Any(), 1, false).DoAndReturn(
+					func(ctx context.Context, id int, includeDeleted bool) (*app.Person, error) {
+						require.Equal(t, "req-1", app.RequestIDFromContext(ctx))
+
+						return &app.Person{Id: 1, Version: 1}, nil
+					})
+			},
+			expectedStatusCode: 200,
+			expectedLevel:      logrus.InfoLevel,
+		}, {
+			name: "Request ID Generated",
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonByID(gomock.Any(), 1, false).
+					Return(nil, fmt.Errorf("%w: person with ID 1 doesn't exist", app.ErrNotFound))
+			},
+			expectedStatusCode: 404,
+			expectedLevel:      logrus.WarnLevel,
+		}, {
+			name:              "Request ID Too Long",
+			requestID:         strings.Repeat("a", maxRequestIDLength+1),
+			requestIDReplaced: true,
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonByID(gomock.Any(), 1, false).Return(&app.Person{Id: 1, Version: 1}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedLevel:      logrus.InfoLevel,
+		}, {
+			name:              "Request ID With Invalid Characters",
+			requestID:         "req-1\nlevel=error",
+			requestIDReplaced: true,
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonByID(gomock.Any(), 1, false).Return(&app.Person{Id: 1, Version: 1}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedLevel:      logrus.InfoLevel,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			hook := test.NewGlobal()
+			defer hook.Reset()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			testCase.mockBehavior(perLog)
+
+			hand := PersonHandler{perLog}
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Use(requestID(), accessLog())
+			r.GET("/person/:id", hand.GetPerson)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/person/1", nil)
+			req.Header.Set(echo.HeaderXRequestID, testCase.requestID)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+
+			id := rec.Header().Get(echo.HeaderXRequestID)
+			require.NotEmpty(t, id)
+
+			if testCase.requestIDReplaced {
+				require.NotEqual(t, testCase.requestID, id)
+			} else if testCase.requestID != "" {
+				require.Equal(t, testCase.requestID, id)
+			}
+
+			// The access log comes first, errors are logged when the error handler renders them afterwards.
+			access := hook.AllEntries()[0]
+			require.Equal(t, "request", access.Message)
+			require.Equal(t, id, access.Data["request_id"])
+			require.Equal(t, "/person/:id", access.Data["route"])
+			require.Equal(t, 1, access.Data["person_id"])
+			require.Equal(t, testCase.expectedStatusCode, access.Data["status"])
+			require.Contains(t, access.Data, "latency_ms")
+			require.Contains(t, access.Data, "bytes_out")
+			require.NotContains(t, access.Data, logrus.ErrorKey, "errors are logged by the error handler only")
+
+			if testCase.expectedLevel != logrus.InfoLevel {
+				require.Len(t, hook.AllEntries(), 2)
+				require.Equal(t, testCase.expectedLevel, hook.AllEntries()[1].Level)
+				require.Equal(t, id, hook.AllEntries()[1].Data["request_id"], "errors must be logged with the request ID")
+			}
+		})
+	}
+}
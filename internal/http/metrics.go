@@ -0,0 +1,40 @@
+package http
+
+import (
+	"errors"
+	"github.com/EgorMamoshkin/person-api-crud/internal/metrics"
+	"github.com/labstack/echo/v4"
+	"time"
+)
+
+// unmatchedRoute labels requests not matching any route, so that arbitrary paths don't become label values.
+const unmatchedRoute = "unmatched"
+
+// NewMetricsHandler measures every request and serves the metrics at /metrics.
+func NewMetricsHandler(e *echo.Echo, m *metrics.Metrics) {
+	e.Use(measureRequests(m))
+
+	e.GET("/metrics", echo.WrapHandler(m.Handler()))
+}
+
+func measureRequests(m *metrics.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+
+			status := responseStatus(c, err)
+
+			// The router reports unmatched requests with these errors, their path stands for the route.
+			route := c.Path()
+			if errors.Is(err, echo.ErrNotFound) || errors.Is(err, echo.ErrMethodNotAllowed) {
+				route = unmatchedRoute
+			}
+
+			m.ObserveRequest(c.Request().Method, route, status, time.Since(start))
+
+			return err
+		}
+	}
+}
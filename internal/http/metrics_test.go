@@ -0,0 +1,45 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app/mock"
+	"github.com/EgorMamoshkin/person-api-crud/internal/metrics"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewMetricsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	perLog := mock_app.NewMockPersonLogic(ctrl)
+	perLog.EXPECT().GetPersonByID(context.Background(), 1, false).Return(&app.Person{Id: 1, Version: 1}, nil)
+	perLog.EXPECT().GetPersonByID(context.Background(), 2, false).
+		Return(nil, fmt.Errorf("%w: person with ID 2 doesn't exist", app.ErrNotFound))
+
+	hand := PersonHandler{perLog}
+
+	r := echo.New()
+	r.HTTPErrorHandler = ErrorHandler
+	NewMetricsHandler(r, metrics.New())
+	r.GET("/person/:id", hand.GetPerson)
+
+	for _, path := range []string{"/person/1", "/person/2", "/unknown/path"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	require.Equal(t, 200, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, `person_api_http_requests_total{method="GET",route="/person/:id",status="200"} 1`)
+	require.Contains(t, body, `person_api_http_requests_total{method="GET",route="/person/:id",status="404"} 1`)
+	require.Contains(t, body, `person_api_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+}
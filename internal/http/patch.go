@@ -0,0 +1,115 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	MIMEMergePatch = "application/merge-patch+json"
+	MIMEJSONPatch  = "application/json-patch+json"
+
+	// maxPatchBody limits the size of a patch, which changes a single person.
+	maxPatchBody = 64 << 10
+)
+
+// mergePatch decodes an RFC 7396 merge patch of a person.
+// Every person field is required, so none of them can be removed with null.
+func mergePatch(body []byte) (app.PersonPatch, error) {
+	var (
+		patch app.PersonPatch
+		doc   map[string]json.RawMessage
+	)
+
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return patch, fmt.Errorf("%w: merge patch must be a JSON object: %s", app.ErrValidation, err)
+	}
+
+	for name, raw := range doc {
+		var dst **string
+
+		switch name {
+		case "email":
+			dst = &patch.Email
+		case "phone":
+			dst = &patch.Phone
+		case "firstName":
+			dst = &patch.FirstName
+		case "lastName":
+			dst = &patch.LastName
+		case "id", "createdAt", "version", "deletedAt":
+			return patch, fmt.Errorf("%w: field %s is read-only", app.ErrValidation, name)
+		default:
+			return patch, fmt.Errorf("%w: unknown field %s", app.ErrValidation, name)
+		}
+
+		if string(raw) == "null" {
+			return patch, fmt.Errorf("%w: field %s can't be removed", app.ErrValidation, name)
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return patch, fmt.Errorf("%w: field %s must be a string", app.ErrValidation, name)
+		}
+
+		*dst = &value
+	}
+
+	return patch, nil
+}
+
+// jsonPatch applies an RFC 6902 JSON patch to the current state of a person
+// and returns the fields it changed.
+func jsonPatch(current *app.Person, body []byte) (app.PersonPatch, error) {
+	var patch app.PersonPatch
+
+	ops, err := jsonpatch.DecodePatch(body)
+	if err != nil {
+		return patch, fmt.Errorf("%w: malformed JSON patch: %s", app.ErrValidation, err)
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return patch, err
+	}
+
+	patched, err := ops.Apply(doc)
+	if err != nil {
+		return patch, fmt.Errorf("%w: can't apply JSON patch: %s", app.ErrValidation, err)
+	}
+
+	var result app.Person
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&result); err != nil {
+		return patch, fmt.Errorf("%w: patched person is invalid: %s", app.ErrValidation, err)
+	}
+
+	if result.Id != current.Id || !result.CreatedAt.Equal(current.CreatedAt) || result.Version != current.Version ||
+		result.DeletedAt != nil {
+		return patch, fmt.Errorf("%w: fields id, createdAt, version and deletedAt are read-only", app.ErrValidation)
+	}
+
+	if result.Email != current.Email {
+		patch.Email = &result.Email
+	}
+
+	if result.Phone != current.Phone {
+		patch.Phone = &result.Phone
+	}
+
+	if result.FirstName != current.FirstName {
+		patch.FirstName = &result.FirstName
+	}
+
+	if result.LastName != current.LastName {
+		patch.LastName = &result.LastName
+	}
+
+	return patch, nil
+}
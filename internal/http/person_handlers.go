@@ -1,47 +1,53 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"github.com/EgorMamoshkin/person-api-crud/internal/app"
-	"github.com/go-playground/validator/v10"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type PersonHandler struct {
 	personLogic app.PersonLogic
 }
 
-func NewPersonHandler(e *echo.Echo, pl app.PersonLogic) {
+// NewPersonHandler registers the person routes, only the admins may read deleted persons.
+func NewPersonHandler(e *echo.Echo, pl app.PersonLogic, phoneRegion string, admins Admins) {
 	handler := &PersonHandler{personLogic: pl}
 
+	e.HTTPErrorHandler = ErrorHandler
+	e.Validator = NewValidator(phoneRegion)
+
+	e.GET("/person/search", handler.SearchPersons)
 	e.GET("/person/:id", handler.GetPerson)
+	e.GET("/person/:id/history", handler.GetPersonHistory)
 	e.POST("/person", handler.StorePerson)
 	e.PUT("/person", handler.UpdatePerson)
+	e.PUT("/person/by-email/:email", handler.UpsertPerson)
+	e.PATCH("/person/:id", handler.PatchPerson)
 	e.DELETE("/person/:id", handler.DeletePerson)
-	e.GET("/person/:offsetId/:batchSize", handler.GetPersonList)
-
-	log := logrus.New()
-
-	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
-		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
-			log.WithFields(logrus.Fields{
-				"URI":    v.URI,
-				"status": v.Status,
-				"ERROR":  v.Error,
-				"Method": v.Method,
-			}).Info("request")
-
-			return nil
-		},
-		LogURI:    true,
-		LogStatus: true,
-		LogError:  true,
-		LogMethod: true,
-	}))
+	e.POST("/person/:id/restore", handler.RestorePerson)
+	e.GET("/person", handler.GetPersonList)
+	e.POST("/person/bulk", handler.StorePersons)
+	e.PUT("/person/bulk", handler.UpdatePersons)
+	e.DELETE("/person/bulk", handler.DeletePersons)
+	e.GET("/person/export", handler.ExportPersons)
+	e.POST("/person/import", handler.ImportPersons)
+
+	e.Use(traceRequests(otel.GetTracerProvider()))
+	e.Use(requestID())
+	e.Use(auditContext(admins))
+	e.Use(accessLog())
 }
 
 func (ph *PersonHandler) StorePerson(c echo.Context) error {
@@ -49,131 +55,410 @@ func (ph *PersonHandler) StorePerson(c echo.Context) error {
 
 	err := c.Bind(&person)
 	if err != nil {
-		logrus.Error(err)
-
-		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetInternal(err)
 	}
 
-	if ok, err := isRequestValid(&person); !ok {
-		logrus.Error(err)
-
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := c.Validate(&person); err != nil {
+		return err
 	}
 
 	ctx := c.Request().Context()
 
 	err = ph.personLogic.StorePerson(ctx, &person)
 	if err != nil {
-		logrus.Error(err)
-
-		return c.JSON(http.StatusNotImplemented, err.Error())
+		return err
 	}
 
+	c.Response().Header().Set(HeaderETag, etag(person.Version))
+
 	return c.JSON(http.StatusOK, person)
 }
 
 func (ph *PersonHandler) GetPerson(c echo.Context) error {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := personID(c)
 	if err != nil {
-		logrus.Error(err)
+		return err
+	}
 
-		return c.JSON(http.StatusBadRequest, err.Error())
+	includeDeleted, err := includeDeletedParam(c)
+	if err != nil {
+		return err
 	}
 
 	ctx := c.Request().Context()
 
-	person, err := ph.personLogic.GetPersonByID(ctx, id)
-	if err != nil {
-		logrus.Error(err)
+	var person *app.Person
+
+	if asOf := c.QueryParam("as_of"); asOf != "" {
+		t, err := time.Parse(time.RFC3339, asOf)
+		if err != nil {
+			return invalidParam("as_of", err)
+		}
+
+		if err := ph.checkNotDeleted(ctx, id); err != nil {
+			return err
+		}
+
+		person, err = ph.personLogic.GetPersonAsOf(ctx, id, t)
+		if err != nil {
+			return err
+		}
+	} else {
+		person, err = ph.personLogic.GetPersonByID(ctx, id, includeDeleted)
+		if err != nil {
+			return err
+		}
+	}
+
+	tag := etag(person.Version)
+	c.Response().Header().Set(HeaderETag, tag)
 
-		return c.JSON(http.StatusNotImplemented, err.Error())
+	if !noneMatch(c, tag) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
 	return c.JSON(http.StatusOK, *person)
 }
 
+func (ph *PersonHandler) GetPersonHistory(c echo.Context) error {
+	id, err := personID(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	if err := ph.checkNotDeleted(ctx, id); err != nil {
+		return err
+	}
+
+	history, err := ph.personLogic.GetPersonHistory(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
 func (ph *PersonHandler) DeletePerson(c echo.Context) error {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := personID(c)
 	if err != nil {
-		logrus.Error(err)
+		return err
+	}
 
-		return c.JSON(http.StatusBadRequest, err.Error())
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
 	}
 
 	ctx := c.Request().Context()
 
-	err = ph.personLogic.DeletePerson(ctx, id)
+	err = ph.personLogic.DeletePerson(ctx, id, version)
 	if err != nil {
-		logrus.Error(err)
-
-		return c.JSON(http.StatusNotImplemented, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, "The person's data has been deleted")
 }
 
+func (ph *PersonHandler) RestorePerson(c echo.Context) error {
+	id, err := personID(c)
+	if err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	person, err := ph.personLogic.RestorePerson(ctx, id, version)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etag(person.Version))
+
+	return c.JSON(http.StatusOK, person)
+}
+
 func (ph *PersonHandler) UpdatePerson(c echo.Context) error {
 	var person app.Person
 
 	err := c.Bind(&person)
 	if err != nil {
-		logrus.Error(err)
-
-		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetInternal(err)
 	}
 
-	if ok, err := isRequestValid(&person); !ok {
-		logrus.Error(err)
+	if err := c.Validate(&person); err != nil {
+		return err
+	}
 
-		return c.JSON(http.StatusBadRequest, err.Error())
+	person.Version, err = ifMatchVersion(c)
+	if err != nil {
+		return err
 	}
 
 	ctx := c.Request().Context()
 
+	logging.AddFields(ctx, logrus.Fields{"person_id": person.Id})
+
 	err = ph.personLogic.UpdatePerson(ctx, &person)
 	if err != nil {
-		logrus.Error(err)
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etag(person.Version))
+
+	return c.JSON(http.StatusOK, person)
+}
+
+// UpsertPerson creates the person with the email of the path or updates the one using it.
+// The update is conditioned on If-Match when it is given, in which case the person must exist.
+func (ph *PersonHandler) UpsertPerson(c echo.Context) error {
+	email, err := url.PathUnescape(c.Param("email"))
+	if err != nil {
+		return invalidParam("email", err)
+	}
+
+	var person app.Person
+
+	err = c.Bind(&person)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetInternal(err)
+	}
+
+	// Emails are compared as the logic normalizes them, e.g. John@Example.com is the same as john@example.com.
+	if person.Email != "" && !strings.EqualFold(strings.TrimSpace(person.Email), strings.TrimSpace(email)) {
+		return fmt.Errorf("%w: email %s doesn't match the one of the path %s", app.ErrValidation, person.Email, email)
+	}
 
-		return c.JSON(http.StatusNotImplemented, err.Error())
+	person = app.Person{Email: email, Phone: person.Phone, FirstName: person.FirstName, LastName: person.LastName}
+
+	if err := c.Validate(&person); err != nil {
+		return err
+	}
+
+	if c.Request().Header.Get(HeaderIfMatch) != "" {
+		if person.Version, err = ifMatchVersion(c); err != nil {
+			return err
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	created, err := ph.personLogic.Upsert(ctx, &person)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etag(person.Version))
+
+	if created {
+		return c.JSON(http.StatusCreated, person)
 	}
 
 	return c.JSON(http.StatusOK, person)
 }
 
-func (ph *PersonHandler) GetPersonList(c echo.Context) error {
-	offsetID, err := strconv.Atoi(c.Param("offsetId"))
+func (ph *PersonHandler) PatchPerson(c echo.Context) error {
+	id, err := personID(c)
+	if err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(limitBody(c, maxPatchBody))
+	if err != nil {
+		return readError(err)
+	}
+
+	ctx := c.Request().Context()
+
+	var patch app.PersonPatch
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+
+	switch mediaType {
+	case MIMEMergePatch, echo.MIMEApplicationJSON:
+		patch, err = mergePatch(body)
+	case MIMEJSONPatch:
+		var current *app.Person
+
+		current, err = ph.personLogic.GetPersonByID(ctx, id, false)
+		if err != nil {
+			return err
+		}
+
+		patch, err = jsonPatch(current, body)
+	default:
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("content type must be %s or %s", MIMEMergePatch, MIMEJSONPatch))
+	}
+
 	if err != nil {
-		logrus.Error(err)
+		return err
+	}
 
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := validatePatch(c, patch); err != nil {
+		return err
 	}
 
-	batchSize, err := strconv.Atoi(c.Param("batchSize"))
+	person, err := ph.personLogic.PatchPerson(ctx, id, version, patch)
 	if err != nil {
-		logrus.Error(err)
+		return err
+	}
+
+	c.Response().Header().Set(HeaderETag, etag(person.Version))
 
-		return c.JSON(http.StatusBadRequest, err.Error())
+	return c.JSON(http.StatusOK, person)
+}
+
+func (ph *PersonHandler) GetPersonList(c echo.Context) error {
+	req, err := listRequest(c)
+	if err != nil {
+		return err
 	}
 
 	ctx := c.Request().Context()
 
-	personList, err := ph.personLogic.GetPersonList(ctx, offsetID, batchSize)
+	page, err := ph.personLogic.GetPersonList(ctx, req)
 	if err != nil {
-		logrus.Error(err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+func (ph *PersonHandler) SearchPersons(c echo.Context) error {
+	limit := 0
 
-		return c.JSON(http.StatusNotImplemented, err.Error())
+	if value := c.QueryParam("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return invalidParam("limit", err)
+		}
+
+		limit = n
 	}
 
-	return c.JSON(http.StatusOK, personList)
+	ctx := c.Request().Context()
+
+	matches, err := ph.personLogic.SearchPersons(ctx, c.QueryParam("q"), limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, matches)
 }
 
-func isRequestValid(p *app.Person) (bool, error) {
-	val := validator.New()
+func listRequest(c echo.Context) (app.ListRequest, error) {
+	req := app.ListRequest{
+		Filter: app.PersonFilter{
+			Email:       c.QueryParam("email"),
+			PhonePrefix: c.QueryParam("phone_prefix"),
+			FirstName:   c.QueryParam("first_name"),
+			LastName:    c.QueryParam("last_name"),
+		},
+		Sort:   app.Sort{Field: app.SortField(c.QueryParam("sort"))},
+		Cursor: c.QueryParam("cursor"),
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return req, invalidParam("limit", err)
+		}
 
-	err := val.Struct(p)
+		req.Limit = n
+	}
+
+	includeDeleted, err := includeDeletedParam(c)
 	if err != nil {
-		return false, fmt.Errorf("invalid request data: %w", err)
+		return req, err
+	}
+
+	req.Filter.IncludeDeleted = includeDeleted
+
+	switch order := c.QueryParam("order"); order {
+	case "", "asc":
+	case "desc":
+		req.Sort.Desc = true
+	default:
+		return req, invalidParam("order", fmt.Errorf("must be asc or desc, got %q", order))
+	}
+
+	for name, dst := range map[string]*time.Time{
+		"created_after":  &req.Filter.CreatedAfter,
+		"created_before": &req.Filter.CreatedBefore,
+	} {
+		value := c.QueryParam(name)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return req, invalidParam(name, err)
+		}
+
+		*dst = t
+	}
+
+	return req, nil
+}
+
+func boolParam(c echo.Context, name string) (bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, invalidParam(name, err)
+	}
+
+	return b, nil
+}
+
+// includeDeletedParam parses the include_deleted flag, which only the admins may set.
+func includeDeletedParam(c echo.Context) (bool, error) {
+	include, err := boolParam(c, "include_deleted")
+	if err != nil || !include {
+		return false, err
+	}
+
+	if !isAdmin(c.Request().Context()) {
+		return false, echo.NewHTTPError(http.StatusForbidden, "include_deleted is only allowed to admins")
 	}
 
 	return true, nil
 }
+
+// checkNotDeleted keeps the past of deleted persons from the callers other than the admins,
+// for them a deleted person isn't found just as it isn't by its ID.
+func (ph *PersonHandler) checkNotDeleted(ctx context.Context, id int) error {
+	if isAdmin(ctx) {
+		return nil
+	}
+
+	_, err := ph.personLogic.GetPersonByID(ctx, id, false)
+
+	return err
+}
+
+// validatePatch checks only the fields the patch changes against the rules of app.Person.
+func validatePatch(c echo.Context, patch app.PersonPatch) error {
+	var person app.Person
+
+	patch.Apply(&person)
+
+	return c.Validate(Partial{Struct: &person, Fields: patch.Fields()})
+}
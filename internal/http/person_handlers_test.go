@@ -14,8 +14,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
+var createdAt = time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+
 func TestPersonHandler_StorePerson(t *testing.T) {
 	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person)
 
@@ -29,11 +33,11 @@ func TestPersonHandler_StorePerson(t *testing.T) {
 	}{
 		{
 			name:      "OK",
-			inputBody: `{"email":"test@gmail.com", "phone":"+1111111111", "firstName":"TestName", "lastname":"Test"}`,
+			inputBody: `{"email":"test@gmail.com", "phone":"+14155550101", "firstName":"TestName", "lastname":"Test"}`,
 			inputUser: &app.Person{
 				Id:        0,
 				Email:     "test@gmail.com",
-				Phone:     "+1111111111",
+				Phone:     "+14155550101",
 				FirstName: "TestName",
 				LastName:  "Test",
 			},
@@ -41,41 +45,70 @@ func TestPersonHandler_StorePerson(t *testing.T) {
 				s.EXPECT().StorePerson(ctx, pers).Return(nil)
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: `{"id":0,"email":"test@gmail.com","phone":"+1111111111","firstName":"TestName","lastName":"Test"}`,
+			expectedRequestBody: `{"id":0,"email":"test@gmail.com","phone":"+14155550101","firstName":"TestName","lastName":"Test","createdAt":"0001-01-01T00:00:00Z","version":0}`,
 		}, {
 			name:                "Empty Fields",
-			inputBody:           `{"phone":"+1111111111", "firstName":"TestName", "lastname":"Test"}`,
+			inputBody:           `{"phone":"+14155550101", "firstName":"TestName", "lastname":"Test"}`,
 			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: `"invalid request data: Key: 'Person.Email' Error:Field validation for 'Email' failed on the 'required' tag"`,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: email is a required field","code":"validation_error","errors":[{"field":"email","rule":"required","message":"email is a required field"}]}`,
 		}, {
 			name:      "Service Failure",
-			inputBody: `{"id":1, "email":"test@gmail.com", "phone":"+1111111111", "firstName":"TestName", "lastname":"Test"}`,
+			inputBody: `{"id":1, "email":"test@gmail.com", "phone":"+14155550101", "firstName":"TestName", "lastname":"Test"}`,
 			inputUser: &app.Person{
 				Id:        1,
 				Email:     "test@gmail.com",
-				Phone:     "+1111111111",
+				Phone:     "+14155550101",
 				FirstName: "TestName",
 				LastName:  "Test",
 			},
 			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {
 				s.EXPECT().StorePerson(context.Background(), pers).Return(errors.New("service failure"))
 			},
-			expectedStatusCode:  501,
-			expectedRequestBody: `"service failure"`,
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"error":{"code":"internal_error","message":"internal server error"}}`,
 		}, {
 			name:      "Unprocessable Entity",
 			inputBody: `111111111`,
 			inputUser: &app.Person{
 				Id:        1,
 				Email:     "test@gmail.com",
-				Phone:     "+1111111111",
+				Phone:     "+14155550101",
 				FirstName: "TestName",
 				LastName:  "Test",
 			},
 			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {},
 			expectedStatusCode:  422,
-			expectedRequestBody: `"code=400, message=Unmarshal type error: expected=app.Person, got=number, field=, offset=9, internal=json: cannot unmarshal number into Go value of type app.Person"`,
+			expectedRequestBody: `{"error":{"code":"unprocessable_entity","message":"code=400, message=Unmarshal type error: expected=app.Person, got=number, field=, offset=9, internal=json: cannot unmarshal number into Go value of type app.Person"}}`,
+		}, {
+			name:      "Email Conflict",
+			inputBody: `{"email":"test@gmail.com", "phone":"+14155550101", "firstName":"TestName", "lastname":"Test"}`,
+			inputUser: &app.Person{
+				Email:     "test@gmail.com",
+				Phone:     "+14155550101",
+				FirstName: "TestName",
+				LastName:  "Test",
+			},
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {
+				s.EXPECT().StorePerson(ctx, pers).Return(fmt.Errorf("%w: test@gmail.com", app.ErrEmailConflict))
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"error":{"code":"email_conflict","message":"email address is already in use: test@gmail.com"}}`,
+		}, {
+			name:      "Invalid Fields",
+			inputBody: `{"email":"test", "phone":"1", "firstName":"TestName", "lastname":"Test"}`,
+			inputUser: &app.Person{
+				Email:     "test",
+				Phone:     "1",
+				FirstName: "TestName",
+				LastName:  "Test",
+			},
+			mockBehavior:       func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {},
+			expectedStatusCode: 400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,` +
+				`"detail":"invalid request data: email must be a valid email address; phone must be a valid phone number","code":"validation_error",` +
+				`"errors":[{"field":"email","rule":"email","message":"email must be a valid email address"},` +
+				`{"field":"phone","rule":"phone","message":"phone must be a valid phone number"}]}`,
 		},
 	}
 
@@ -94,11 +127,17 @@ func TestPersonHandler_StorePerson(t *testing.T) {
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
 			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
 			r.POST("/person", hand.StorePerson)
 
 			r.ServeHTTP(rec, req)
 
 			assert.Equal(t, rec.Code, testCase.expectedStatusCode)
+
+			if testCase.expectedStatusCode == 400 {
+				assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+			}
 			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
 		})
 	}
@@ -108,8 +147,10 @@ func TestPersonHandler_GetPerson(t *testing.T) {
 	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context, id any)
 
 	testTable := []struct {
-		name    string
-		inputID any
+		name        string
+		inputID     any
+		query       string
+		ifNoneMatch string
 		mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
@@ -118,24 +159,97 @@ func TestPersonHandler_GetPerson(t *testing.T) {
 			name:    "OK",
 			inputID: 1,
 			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
-				s.EXPECT().GetPersonByID(ctx, id).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test"}, nil)
+				s.EXPECT().GetPersonByID(ctx, id, false).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 1}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":1}`,
+		}, {
+			name:        "Not Modified",
+			inputID:     1,
+			ifNoneMatch: `"1"`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
+				s.EXPECT().GetPersonByID(ctx, id, false).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 1}, nil)
+			},
+			expectedStatusCode:  304,
+			expectedRequestBody: ``,
+		}, {
+			name:        "Modified",
+			inputID:     1,
+			ifNoneMatch: `"0", W/"2"`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
+				s.EXPECT().GetPersonByID(ctx, id, false).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 3}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":3}`,
+		}, {
+			name:                "Include Deleted Not Allowed",
+			inputID:             1,
+			query:               "?include_deleted=true",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"include_deleted is only allowed to admins"}}`,
+		}, {
+			name:    "As Of",
+			inputID: 1,
+			query:   "?as_of=2022-11-02T00:00:00Z",
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
+				s.EXPECT().GetPersonByID(ctx, id, false).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 2}, nil)
+				s.EXPECT().GetPersonAsOf(ctx, id, time.Date(2022, 11, 2, 0, 0, 0, 0, time.UTC)).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 1}, nil)
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test"}`,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":1}`,
+		}, {
+			name:                "Wrong As Of",
+			inputID:             1,
+			query:               "?as_of=yesterday",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: invalid parameter as_of: parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\"","code":"validation_error"}`,
+		}, {
+			name:                "Wrong Include Deleted",
+			inputID:             1,
+			query:               "?include_deleted=maybe",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: invalid parameter include_deleted: strconv.ParseBool: parsing \"maybe\": invalid syntax","code":"validation_error"}`,
 		}, {
 			name:                "Wrong ID",
 			inputID:             "a",
 			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: `"strconv.Atoi: parsing \"a\": invalid syntax"`,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: invalid parameter id: strconv.Atoi: parsing \"a\": invalid syntax","code":"validation_error"}`,
 		}, {
 			name:    "Service Failure",
 			inputID: 0,
 			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
-				s.EXPECT().GetPersonByID(ctx, id).Return(nil, errors.New("service failure"))
+				s.EXPECT().GetPersonByID(ctx, id, false).Return(nil, errors.New("service failure"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"error":{"code":"internal_error","message":"internal server error"}}`,
+		}, {
+			name:    "Not Found",
+			inputID: 2,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
+				s.EXPECT().GetPersonByID(ctx, id, false).Return(nil, fmt.Errorf("%w: person with ID 2 doesn't exist", app.ErrNotFound))
 			},
-			expectedStatusCode:  501,
-			expectedRequestBody: `"service failure"`,
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"person not found: person with ID 2 doesn't exist"}}`,
+		}, {
+			name:    "Storage Unavailable",
+			inputID: 1,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
+				s.EXPECT().GetPersonByID(ctx, id, false).Return(nil, fmt.Errorf("%w: connection refused", app.ErrUnavailable))
+			},
+			expectedStatusCode:  503,
+			expectedRequestBody: `{"error":{"code":"unavailable","message":"storage is unavailable"}}`,
+		}, {
+			name:    "Storage Timeout",
+			inputID: 1,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
+				s.EXPECT().GetPersonByID(ctx, id, false).Return(nil, fmt.Errorf("%w: context deadline exceeded", app.ErrTimeout))
+			},
+			expectedStatusCode:  504,
+			expectedRequestBody: `{"error":{"code":"timeout","message":"storage has timed out"}}`,
 		},
 	}
 	for _, testCase := range testTable {
@@ -149,10 +263,16 @@ func TestPersonHandler_GetPerson(t *testing.T) {
 			hand := PersonHandler{perLog}
 
 			rec := httptest.NewRecorder()
-			req := httptest.NewRequest("GET", fmt.Sprintf("/person/%v", testCase.inputID), nil)
+			req := httptest.NewRequest("GET", fmt.Sprintf("/person/%v%s", testCase.inputID, testCase.query), nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
+			if testCase.ifNoneMatch != "" {
+				req.Header.Set(HeaderIfNoneMatch, testCase.ifNoneMatch)
+			}
+
 			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
 			r.GET("/person/:id", hand.GetPerson)
 
 			r.ServeHTTP(rec, req)
@@ -164,12 +284,202 @@ func TestPersonHandler_GetPerson(t *testing.T) {
 
 }
 
+func TestPersonHandler_DeletedPersons(t *testing.T) {
+	createdAt := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		path                string
+		actor               string
+		remoteAddr          string
+		mockBehavior        func(s *mock_app.MockPersonLogic)
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:  "Admin",
+			path:  "/person/1?include_deleted=true",
+			actor: "admin",
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonByID(gomock.Any(), 1, true).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 2, DeletedAt: &createdAt}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":2,"deletedAt":"2022-11-01T12:00:00Z"}`,
+		}, {
+			name:                "Other Actor",
+			path:                "/person/1?include_deleted=true",
+			actor:               "user",
+			mockBehavior:        func(s *mock_app.MockPersonLogic) {},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"include_deleted is only allowed to admins"}}`,
+		}, {
+			name:                "Admin Not Through Proxy",
+			path:                "/person/1?include_deleted=true",
+			actor:               "admin",
+			remoteAddr:          "203.0.113.1:1234",
+			mockBehavior:        func(s *mock_app.MockPersonLogic) {},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"include_deleted is only allowed to admins"}}`,
+		}, {
+			name:  "Deleted As Of",
+			path:  "/person/1?as_of=2022-11-02T00:00:00Z",
+			actor: "user",
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonByID(gomock.Any(), 1, false).Return(nil, fmt.Errorf("%w: person with ID 1 doesn't exist", app.ErrNotFound))
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"person not found: person with ID 1 doesn't exist"}}`,
+		}, {
+			name:  "Deleted As Of By Admin",
+			path:  "/person/1?as_of=2022-11-02T00:00:00Z",
+			actor: "admin",
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonAsOf(gomock.Any(), 1, time.Date(2022, 11, 2, 0, 0, 0, 0, time.UTC)).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 1}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":1}`,
+		}, {
+			name: "Deleted History",
+			path: "/person/1/history",
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonByID(gomock.Any(), 1, false).Return(nil, fmt.Errorf("%w: person with ID 1 doesn't exist", app.ErrNotFound))
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"person not found: person with ID 1 doesn't exist"}}`,
+		}, {
+			name:  "Deleted History By Admin",
+			path:  "/person/1/history",
+			actor: "admin",
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonHistory(gomock.Any(), 1).Return([]app.AuditRecord{{ID: 2, PersonID: 1, Action: app.ActionDelete, Actor: "admin", CreatedAt: createdAt}}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `[{"id":2,"personId":1,"action":"delete","actor":"admin","changes":null,"createdAt":"2022-11-01T12:00:00Z"}]`,
+		}, {
+			name:                "Anonymous List",
+			path:                "/person?include_deleted=true",
+			mockBehavior:        func(s *mock_app.MockPersonLogic) {},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"error":{"code":"forbidden","message":"include_deleted is only allowed to admins"}}`,
+		}, {
+			name:  "Not Included",
+			path:  "/person/1?include_deleted=false",
+			actor: "user",
+			mockBehavior: func(s *mock_app.MockPersonLogic) {
+				s.EXPECT().GetPersonByID(gomock.Any(), 1, false).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 1}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":1}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			testCase.mockBehavior(perLog)
+
+			hand := PersonHandler{perLog}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.path, nil)
+
+			if testCase.actor != "" {
+				req.Header.Set(HeaderActor, testCase.actor)
+			}
+
+			if testCase.remoteAddr != "" {
+				req.RemoteAddr = testCase.remoteAddr
+			}
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			// The requests of httptest come from 192.0.2.1.
+			r.Use(auditContext(Admins{Names: []string{"admin"}, Proxies: []string{"192.0.2.0/24"}}))
+			r.GET("/person/:id", hand.GetPerson)
+			r.GET("/person/:id/history", hand.GetPersonHistory)
+			r.GET("/person", hand.GetPersonList)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+}
+
+func TestPersonHandler_GetPersonHistory(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context)
+
+	testTable := []struct {
+		name string
+		mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().GetPersonByID(ctx, 1, false).Return(&app.Person{Id: 1, Version: 2}, nil)
+				s.EXPECT().GetPersonHistory(ctx, 1).Return([]app.AuditRecord{{
+					ID:        1,
+					PersonID:  1,
+					Action:    app.ActionUpdate,
+					Actor:     "operator",
+					RequestID: "req-1",
+					Changes:   app.Changes{"lastName": {Before: []byte(`"Smith"`), After: []byte(`"Smithers"`)}},
+					CreatedAt: createdAt,
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `[{"id":1,"personId":1,"action":"update","actor":"operator","requestId":"req-1",` +
+				`"changes":{"lastName":{"before":"Smith","after":"Smithers"}},"createdAt":"2022-11-01T12:00:00Z"}]`,
+		}, {
+			name: "Not Found",
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().GetPersonByID(ctx, 1, false).Return(&app.Person{Id: 1, Version: 2}, nil)
+				s.EXPECT().GetPersonHistory(ctx, 1).Return(nil, fmt.Errorf("%w: person with ID 1 has no history", app.ErrNotFound))
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"error":{"code":"not_found","message":"person not found: person with ID 1 has no history"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			testCase.mockBehavior(perLog, context.Background())
+
+			hand := PersonHandler{perLog}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/person/1/history", nil)
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
+			r.GET("/person/:id/history", hand.GetPersonHistory)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, testCase.expectedStatusCode)
+			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+}
+
 func TestPersonHandler_DeletePerson(t *testing.T) {
 	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context, id any)
 
 	testTable := []struct {
 		name    string
 		inputID any
+		ifMatch string
 		mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
@@ -177,25 +487,50 @@ func TestPersonHandler_DeletePerson(t *testing.T) {
 		{
 			name:    "OK",
 			inputID: 0,
+			ifMatch: `"3"`,
 			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
-				s.EXPECT().DeletePerson(ctx, id).Return(nil)
+				s.EXPECT().DeletePerson(ctx, id, 3).Return(nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `"The person's data has been deleted"`,
 		}, {
 			name:                "Wrong ID",
 			inputID:             "a",
+			ifMatch:             `"3"`,
 			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: `"strconv.Atoi: parsing \"a\": invalid syntax"`,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: invalid parameter id: strconv.Atoi: parsing \"a\": invalid syntax","code":"validation_error"}`,
+		}, {
+			name:                "Missing If-Match",
+			inputID:             0,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {},
+			expectedStatusCode:  428,
+			expectedRequestBody: `{"error":{"code":"precondition_required","message":"If-Match header with the person ETag is required"}}`,
+		}, {
+			name:                "Malformed If-Match",
+			inputID:             0,
+			ifMatch:             `W/"3"`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: malformed If-Match header W/\"3\"","code":"validation_error"}`,
+		}, {
+			name:    "Stale Version",
+			inputID: 0,
+			ifMatch: `"3"`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
+				s.EXPECT().DeletePerson(ctx, id, 3).Return(fmt.Errorf("%w: expected version 3, current is 4", app.ErrVersionMismatch))
+			},
+			expectedStatusCode:  412,
+			expectedRequestBody: `{"error":{"code":"precondition_failed","message":"person has been modified: expected version 3, current is 4"}}`,
 		}, {
 			name:    "Service Failure",
 			inputID: 0,
+			ifMatch: `"3"`,
 			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, id any) {
-				s.EXPECT().DeletePerson(ctx, id).Return(errors.New("service failure"))
+				s.EXPECT().DeletePerson(ctx, id, 3).Return(errors.New("service failure"))
 			},
-			expectedStatusCode:  501,
-			expectedRequestBody: `"service failure"`,
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"error":{"code":"internal_error","message":"internal server error"}}`,
 		},
 	}
 	for _, testCase := range testTable {
@@ -212,7 +547,13 @@ func TestPersonHandler_DeletePerson(t *testing.T) {
 			req := httptest.NewRequest("DELETE", fmt.Sprintf("/person/%v", testCase.inputID), nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
+			if testCase.ifMatch != "" {
+				req.Header.Set(HeaderIfMatch, testCase.ifMatch)
+			}
+
 			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
 			r.DELETE("/person/:id", hand.DeletePerson)
 
 			r.ServeHTTP(rec, req)
@@ -224,66 +565,302 @@ func TestPersonHandler_DeletePerson(t *testing.T) {
 
 }
 
+func TestPersonHandler_RestorePerson(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context)
+
+	testTable := []struct {
+		name    string
+		ifMatch string
+		mockBehavior
+		expectedStatusCode  int
+		expectedETag        string
+		expectedRequestBody string
+	}{
+		{
+			name:    "OK",
+			ifMatch: `"2"`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().RestorePerson(ctx, 1, 2).Return(&app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 3}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedETag:        `"3"`,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":3}`,
+		}, {
+			name:                "Missing If-Match",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  428,
+			expectedRequestBody: `{"error":{"code":"precondition_required","message":"If-Match header with the person ETag is required"}}`,
+		}, {
+			name:    "Not Deleted",
+			ifMatch: `"2"`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().RestorePerson(ctx, 1, 2).Return(nil, fmt.Errorf("%w: person with ID 1", app.ErrNotDeleted))
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"error":{"code":"not_deleted","message":"person is not deleted: person with ID 1"}}`,
+		}, {
+			name:    "Email Taken",
+			ifMatch: `"2"`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().RestorePerson(ctx, 1, 2).Return(nil, fmt.Errorf("%w: email address of person with ID 1 has been taken since deletion", app.ErrEmailConflict))
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"error":{"code":"email_conflict","message":"email address is already in use: email address of person with ID 1 has been taken since deletion"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			testCase.mockBehavior(perLog, context.Background())
+
+			hand := PersonHandler{perLog}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/person/1/restore", nil)
+
+			if testCase.ifMatch != "" {
+				req.Header.Set(HeaderIfMatch, testCase.ifMatch)
+			}
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
+			r.POST("/person/:id/restore", hand.RestorePerson)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, testCase.expectedStatusCode)
+			require.Equal(t, testCase.expectedETag, rec.Header().Get(HeaderETag))
+			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+}
+
+func TestPersonHandler_UpsertPerson(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context)
+
+	input := app.Person{Email: "test@gmail.com", Phone: "+14155550101", FirstName: "Test", LastName: "Test"}
+
+	testTable := []struct {
+		name      string
+		email     string
+		ifMatch   string
+		inputBody string
+		mockBehavior
+		expectedStatusCode  int
+		expectedETag        string
+		expectedRequestBody string
+	}{
+		{
+			name:      "Created",
+			email:     "test@gmail.com",
+			inputBody: `{"phone":"+14155550101","firstName":"Test","lastName":"Test"}`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().Upsert(ctx, &input).DoAndReturn(func(_ context.Context, per *app.Person) (bool, error) {
+					per.Id, per.CreatedAt, per.Version = 1, createdAt, 1
+
+					return true, nil
+				})
+			},
+			expectedStatusCode:  201,
+			expectedETag:        `"1"`,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":1}`,
+		}, {
+			name:      "Updated",
+			email:     "test%40gmail.com",
+			inputBody: `{"id":7,"email":"test@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test","version":9}`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().Upsert(ctx, &input).DoAndReturn(func(_ context.Context, per *app.Person) (bool, error) {
+					per.Id, per.CreatedAt, per.Version = 1, createdAt, 4
+
+					return false, nil
+				})
+			},
+			expectedStatusCode:  200,
+			expectedETag:        `"4"`,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":4}`,
+		}, {
+			name:      "Email Differs In Case Only",
+			email:     "test@gmail.com",
+			inputBody: `{"id":7,"email":" Test@Gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test","version":9}`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().Upsert(ctx, &input).DoAndReturn(func(_ context.Context, per *app.Person) (bool, error) {
+					per.Id, per.CreatedAt, per.Version = 1, createdAt, 4
+
+					return false, nil
+				})
+			},
+			expectedStatusCode:  200,
+			expectedETag:        `"4"`,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":4}`,
+		}, {
+			name:      "If-Match",
+			email:     "test@gmail.com",
+			ifMatch:   `"3"`,
+			inputBody: `{"phone":"+14155550101","firstName":"Test","lastName":"Test"}`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				expected := input
+				expected.Version = 3
+
+				s.EXPECT().Upsert(ctx, &expected).DoAndReturn(func(_ context.Context, per *app.Person) (bool, error) {
+					per.Id, per.CreatedAt, per.Version = 1, createdAt, 4
+
+					return false, nil
+				})
+			},
+			expectedStatusCode:  200,
+			expectedETag:        `"4"`,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":4}`,
+		}, {
+			name:                "Malformed If-Match",
+			email:               "test@gmail.com",
+			ifMatch:             `W/"3"`,
+			inputBody:           `{"phone":"+14155550101","firstName":"Test","lastName":"Test"}`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: malformed If-Match header W/\"3\"","code":"validation_error"}`,
+		}, {
+			name:                "Email Mismatch",
+			email:               "test@gmail.com",
+			inputBody:           `{"email":"other@gmail.com","phone":"+14155550101","firstName":"Test","lastName":"Test"}`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: email other@gmail.com doesn't match the one of the path test@gmail.com","code":"validation_error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			testCase.mockBehavior(perLog, context.Background())
+
+			hand := PersonHandler{perLog}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/person/by-email/"+testCase.email, bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			if testCase.ifMatch != "" {
+				req.Header.Set(HeaderIfMatch, testCase.ifMatch)
+			}
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
+			r.PUT("/person/by-email/:email", hand.UpsertPerson)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, testCase.expectedStatusCode)
+			require.Equal(t, testCase.expectedETag, rec.Header().Get(HeaderETag))
+			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+}
+
 func TestPersonHandler_UpdatePerson(t *testing.T) {
 	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person)
 
 	testTable := []struct {
 		name      string
 		inputBody string
+		ifMatch   string
 		inputUser *app.Person
 		mockBehavior
 		expectedStatusCode  int
+		expectedETag        string
 		expectedRequestBody string
 	}{
 		{
 			name:      "OK",
-			inputBody: `{"id":1, "email":"test@gmail.com", "phone":"+1111111111", "firstName":"TestName", "lastname":"Test"}`,
+			inputBody: `{"id":1, "email":"test@gmail.com", "phone":"+14155550101", "firstName":"TestName", "lastname":"Test"}`,
+			ifMatch:   `"1"`,
 			inputUser: &app.Person{
 				Id:        1,
 				Email:     "test@gmail.com",
-				Phone:     "+1111111111",
+				Phone:     "+14155550101",
 				FirstName: "TestName",
 				LastName:  "Test",
+				Version:   1,
 			},
 			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {
-				s.EXPECT().UpdatePerson(ctx, pers).Return(nil)
+				s.EXPECT().UpdatePerson(ctx, pers).DoAndReturn(func(ctx context.Context, per *app.Person) error {
+					per.Version = 2
+
+					return nil
+				})
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+1111111111","firstName":"TestName","lastName":"Test"}`,
+			expectedETag:        `"2"`,
+			expectedRequestBody: `{"id":1,"email":"test@gmail.com","phone":"+14155550101","firstName":"TestName","lastName":"Test","createdAt":"0001-01-01T00:00:00Z","version":2}`,
 		}, {
 			name:                "Empty Fields",
-			inputBody:           `{"phone":"+1111111111", "firstName":"TestName", "lastname":"Test"}`,
+			inputBody:           `{"phone":"+14155550101", "firstName":"TestName", "lastname":"Test"}`,
+			ifMatch:             `"1"`,
 			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: `"invalid request data: Key: 'Person.Email' Error:Field validation for 'Email' failed on the 'required' tag"`,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: email is a required field","code":"validation_error","errors":[{"field":"email","rule":"required","message":"email is a required field"}]}`,
+		}, {
+			name:                "Missing If-Match",
+			inputBody:           `{"id":1, "email":"test@gmail.com", "phone":"+14155550101", "firstName":"TestName", "lastname":"Test"}`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {},
+			expectedStatusCode:  428,
+			expectedRequestBody: `{"error":{"code":"precondition_required","message":"If-Match header with the person ETag is required"}}`,
+		}, {
+			name:      "Stale Version",
+			inputBody: `{"id":1, "email":"test@gmail.com", "phone":"+14155550101", "firstName":"TestName", "lastname":"Test"}`,
+			ifMatch:   `"1"`,
+			inputUser: &app.Person{
+				Id:        1,
+				Email:     "test@gmail.com",
+				Phone:     "+14155550101",
+				FirstName: "TestName",
+				LastName:  "Test",
+				Version:   1,
+			},
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {
+				s.EXPECT().UpdatePerson(ctx, pers).Return(fmt.Errorf("%w: expected version 1, current is 2", app.ErrVersionMismatch))
+			},
+			expectedStatusCode:  412,
+			expectedRequestBody: `{"error":{"code":"precondition_failed","message":"person has been modified: expected version 1, current is 2"}}`,
 		}, {
 			name:      "Service Failure",
-			inputBody: `{"id":1, "email":"test@gmail.com", "phone":"+1111111111", "firstName":"TestName", "lastname":"Test"}`,
+			inputBody: `{"id":1, "email":"test@gmail.com", "phone":"+14155550101", "firstName":"TestName", "lastname":"Test"}`,
+			ifMatch:   `"1"`,
 			inputUser: &app.Person{
 				Id:        1,
 				Email:     "test@gmail.com",
-				Phone:     "+1111111111",
+				Phone:     "+14155550101",
 				FirstName: "TestName",
 				LastName:  "Test",
+				Version:   1,
 			},
 			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {
 				s.EXPECT().UpdatePerson(context.Background(), pers).Return(errors.New("service failure"))
 			},
-			expectedStatusCode:  501,
-			expectedRequestBody: `"service failure"`,
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"error":{"code":"internal_error","message":"internal server error"}}`,
 		}, {
 			name:      "Unprocessable Entity",
 			inputBody: `111111111`,
+			ifMatch:   `"1"`,
 			inputUser: &app.Person{
 				Id:        1,
 				Email:     "test@gmail.com",
-				Phone:     "+1111111111",
+				Phone:     "+14155550101",
 				FirstName: "TestName",
 				LastName:  "Test",
 			},
 			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, pers *app.Person) {},
 			expectedStatusCode:  422,
-			expectedRequestBody: `"code=400, message=Unmarshal type error: expected=app.Person, got=number, field=, offset=9, internal=json: cannot unmarshal number into Go value of type app.Person"`,
+			expectedRequestBody: `{"error":{"code":"unprocessable_entity","message":"code=400, message=Unmarshal type error: expected=app.Person, got=number, field=, offset=9, internal=json: cannot unmarshal number into Go value of type app.Person"}}`,
 		},
 	}
 
@@ -301,53 +878,103 @@ func TestPersonHandler_UpdatePerson(t *testing.T) {
 			req := httptest.NewRequest("PUT", "/person", bytes.NewBufferString(testCase.inputBody))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
+			if testCase.ifMatch != "" {
+				req.Header.Set(HeaderIfMatch, testCase.ifMatch)
+			}
+
 			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
 			r.PUT("/person", hand.UpdatePerson)
 
 			r.ServeHTTP(rec, req)
 
 			assert.Equal(t, rec.Code, testCase.expectedStatusCode)
+			require.Equal(t, testCase.expectedETag, rec.Header().Get(HeaderETag))
 			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
 		})
 	}
 }
 
 func TestPersonHandler_GetPersonList(t *testing.T) {
-	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context, offsetId int, batchSize any)
+	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context, req app.ListRequest)
 
 	testTable := []struct {
 		name       string
-		inputID    int
-		inputBSize any
+		inputQuery string
+		inputReq   app.ListRequest
 		mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
 	}{
 		{
 			name:       "OK",
-			inputID:    0,
-			inputBSize: 2,
-			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, offsetId int, batchSize any) {
-				s.EXPECT().GetPersonList(ctx, offsetId, batchSize).Return([]app.Person{{1, "test@gmail.com", "+111111", "Test", "Test"}}, nil)
+			inputQuery: "?limit=1",
+			inputReq:   app.ListRequest{Limit: 1},
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, req app.ListRequest) {
+				s.EXPECT().GetPersonList(ctx, req).Return(&app.PersonPage{
+					Persons:    []app.Person{{Id: 1, Email: "test@gmail.com", Phone: "+111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 1}},
+					NextCursor: "eyJpZCI6MX0",
+				}, nil)
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: `[{"id":1,"email":"test@gmail.com","phone":"+111111","firstName":"Test","lastName":"Test"}]`,
+			expectedRequestBody: `{"persons":[{"id":1,"email":"test@gmail.com","phone":"+111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":1}],"next_cursor":"eyJpZCI6MX0"}`,
 		}, {
-			name:                "Wrong ID",
-			inputID:             0,
-			inputBSize:          "a",
-			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, offsetId int, batchSize any) {},
+			name:       "Last Page",
+			inputQuery: "?cursor=eyJpZCI6MX0",
+			inputReq:   app.ListRequest{Cursor: "eyJpZCI6MX0"},
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, req app.ListRequest) {
+				s.EXPECT().GetPersonList(ctx, req).Return(&app.PersonPage{Persons: []app.Person{}}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"persons":[]}`,
+		}, {
+			name:       "Filtered And Sorted",
+			inputQuery: "?email=test@gmail.com&phone_prefix=%2B1&first_name=te&last_name=st&created_after=2022-01-01T00:00:00Z&sort=last_name&order=desc",
+			inputReq: app.ListRequest{
+				Filter: app.PersonFilter{
+					Email:        "test@gmail.com",
+					PhonePrefix:  "+1",
+					FirstName:    "te",
+					LastName:     "st",
+					CreatedAfter: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+				Sort: app.Sort{Field: app.SortByLastName, Desc: true},
+			},
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, req app.ListRequest) {
+				s.EXPECT().GetPersonList(ctx, req).Return(&app.PersonPage{Persons: []app.Person{}}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"persons":[]}`,
+		}, {
+			name:                "Wrong Order",
+			inputQuery:          "?order=up",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, req app.ListRequest) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: invalid parameter order: must be asc or desc, got \"up\"","code":"validation_error"}`,
+		}, {
+			name:                "Wrong Limit",
+			inputQuery:          "?limit=a",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context, req app.ListRequest) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: invalid parameter limit: strconv.Atoi: parsing \"a\": invalid syntax","code":"validation_error"}`,
+		}, {
+			name:       "Malformed Cursor",
+			inputQuery: "?cursor=abc",
+			inputReq:   app.ListRequest{Cursor: "abc"},
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, req app.ListRequest) {
+				s.EXPECT().GetPersonList(ctx, req).Return(nil, fmt.Errorf("%w: malformed cursor", app.ErrValidation))
+			},
 			expectedStatusCode:  400,
-			expectedRequestBody: `"strconv.Atoi: parsing \"a\": invalid syntax"`,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: malformed cursor","code":"validation_error"}`,
 		}, {
 			name:       "Service Failure",
-			inputID:    0,
-			inputBSize: 2,
-			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, offsetId int, batchSize any) {
-				s.EXPECT().GetPersonList(ctx, offsetId, batchSize).Return(nil, errors.New("service failure"))
+			inputQuery: "",
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context, req app.ListRequest) {
+				s.EXPECT().GetPersonList(ctx, req).Return(nil, errors.New("service failure"))
 			},
-			expectedStatusCode:  501,
-			expectedRequestBody: `"service failure"`,
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"error":{"code":"internal_error","message":"internal server error"}}`,
 		},
 	}
 	for _, testCase := range testTable {
@@ -356,16 +983,82 @@ func TestPersonHandler_GetPersonList(t *testing.T) {
 			defer ctrl.Finish()
 
 			perLog := mock_app.NewMockPersonLogic(ctrl)
-			testCase.mockBehavior(perLog, context.Background(), testCase.inputID, testCase.inputBSize)
+			testCase.mockBehavior(perLog, context.Background(), testCase.inputReq)
 
 			hand := PersonHandler{perLog}
 
 			rec := httptest.NewRecorder()
-			req := httptest.NewRequest("GET", fmt.Sprintf("/person/%v/%v", testCase.inputID, testCase.inputBSize), nil)
+			req := httptest.NewRequest("GET", "/person"+testCase.inputQuery, nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
 			r := echo.New()
-			r.GET("/person/:offsetId/:batchSize", hand.GetPersonList)
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
+			r.GET("/person", hand.GetPersonList)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, testCase.expectedStatusCode)
+			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+
+}
+
+func TestPersonHandler_SearchPersons(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context)
+
+	testTable := []struct {
+		name       string
+		inputQuery string
+		mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:       "OK",
+			inputQuery: "?q=jonh&limit=5",
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().SearchPersons(ctx, "jonh", 5).Return([]app.PersonMatch{{
+					Person: app.Person{Id: 1, Email: "john@gmail.com", Phone: "+111111", FirstName: "John", LastName: "Test", CreatedAt: createdAt, Version: 1},
+					Score:  0.75,
+				}}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `[{"id":1,"email":"john@gmail.com","phone":"+111111","firstName":"John","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":1,"score":0.75}]`,
+		}, {
+			name:                "Wrong Limit",
+			inputQuery:          "?q=jonh&limit=a",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: invalid parameter limit: strconv.Atoi: parsing \"a\": invalid syntax","code":"validation_error"}`,
+		}, {
+			name:       "Empty Query",
+			inputQuery: "",
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().SearchPersons(ctx, "", 0).Return(nil, fmt.Errorf("%w: search query must not be empty", app.ErrValidation))
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: search query must not be empty","code":"validation_error"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			testCase.mockBehavior(perLog, context.Background())
+
+			hand := PersonHandler{perLog}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/person/search"+testCase.inputQuery, nil)
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
+			r.GET("/person/search", hand.SearchPersons)
 
 			r.ServeHTTP(rec, req)
 
@@ -373,5 +1066,143 @@ func TestPersonHandler_GetPersonList(t *testing.T) {
 			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
 		})
 	}
+}
+
+func TestPersonHandler_PatchPerson(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context)
+
+	email := "new@gmail.com"
+	current := &app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 1}
+	patched := &app.Person{Id: 1, Email: email, Phone: "+1111111", FirstName: "Test", LastName: "Test", CreatedAt: createdAt, Version: 2}
+
+	testTable := []struct {
+		name        string
+		contentType string
+		inputBody   string
+		mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:        "Merge Patch",
+			contentType: MIMEMergePatch,
+			inputBody:   `{"email":"new@gmail.com"}`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().PatchPerson(ctx, 1, 1, app.PersonPatch{Email: &email}).Return(patched, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1,"email":"new@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":2}`,
+		}, {
+			name:        "JSON Patch",
+			contentType: MIMEJSONPatch,
+			inputBody:   `[{"op":"test","path":"/email","value":"test@gmail.com"},{"op":"replace","path":"/email","value":"new@gmail.com"}]`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().GetPersonByID(ctx, 1, false).Return(current, nil)
+				s.EXPECT().PatchPerson(ctx, 1, 1, app.PersonPatch{Email: &email}).Return(patched, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1,"email":"new@gmail.com","phone":"+1111111","firstName":"Test","lastName":"Test","createdAt":"2022-11-01T12:00:00Z","version":2}`,
+		}, {
+			name:        "JSON Patch Failed Test",
+			contentType: MIMEJSONPatch,
+			inputBody:   `[{"op":"test","path":"/email","value":"other@gmail.com"}]`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().GetPersonByID(ctx, 1, false).Return(current, nil)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: can't apply JSON patch: testing value /email failed: test failed","code":"validation_error"}`,
+		}, {
+			name:                "Null Field",
+			contentType:         MIMEMergePatch,
+			inputBody:           `{"email":null}`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: field email can't be removed","code":"validation_error"}`,
+		}, {
+			name:                "Empty Field",
+			contentType:         MIMEMergePatch,
+			inputBody:           `{"lastName":""}`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: lastName is a required field","code":"validation_error","errors":[{"field":"lastName","rule":"required","message":"lastName is a required field"}]}`,
+		}, {
+			name:                "Read-only Field",
+			contentType:         MIMEMergePatch,
+			inputBody:           `{"id":2}`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: field id is read-only","code":"validation_error"}`,
+		}, {
+			name:                "Unsupported Media Type",
+			contentType:         echo.MIMETextPlain,
+			inputBody:           `email=new@gmail.com`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  415,
+			expectedRequestBody: `{"error":{"code":"unsupported_media_type","message":"content type must be application/merge-patch+json or application/json-patch+json"}}`,
+		}, {
+			name:                "Body Too Large",
+			contentType:         MIMEMergePatch,
+			inputBody:           `{"lastName":"` + strings.Repeat("a", maxPatchBody) + `"}`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  413,
+			expectedRequestBody: `{"error":{"code":"request_entity_too_large","message":"request body must not be larger than 65536 bytes"}}`,
+		}, {
+			name:        "Email Conflict",
+			contentType: MIMEMergePatch,
+			inputBody:   `{"email":"new@gmail.com"}`,
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().PatchPerson(ctx, 1, 1, app.PersonPatch{Email: &email}).Return(nil, fmt.Errorf("%w: new@gmail.com", app.ErrEmailConflict))
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"error":{"code":"email_conflict","message":"email address is already in use: new@gmail.com"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			testCase.mockBehavior(perLog, context.Background())
+
+			hand := PersonHandler{perLog}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/person/1", bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, testCase.contentType)
+			req.Header.Set(HeaderIfMatch, `"1"`)
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
+			r.PATCH("/person/:id", hand.PatchPerson)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, testCase.expectedStatusCode)
+			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+}
+
+func TestPersonHandler_PatchPerson_ReadError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hand := PersonHandler{mock_app.NewMockPersonLogic(ctrl)}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("PATCH", "/person/1", iotest.ErrReader(errors.New("connection reset")))
+	req.Header.Set(echo.HeaderContentType, MIMEMergePatch)
+	req.Header.Set(HeaderIfMatch, `"1"`)
+
+	r := echo.New()
+	r.HTTPErrorHandler = ErrorHandler
+	r.PATCH("/person/:id", hand.PatchPerson)
+
+	r.ServeHTTP(rec, req)
 
+	assert.Equal(t, 400, rec.Code)
+	require.Equal(t, `{"error":{"code":"bad_request","message":"can't read request body"}}`, strings.TrimRight(rec.Body.String(), "\n"))
 }
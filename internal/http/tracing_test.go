@@ -0,0 +1,59 @@
+package http
+
+import (
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app/mock"
+	"github.com/EgorMamoshkin/person-api-crud/internal/tracing"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTraceRequests(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	perLog := mock_app.NewMockPersonLogic(ctrl)
+	perLog.EXPECT().GetPersonByID(gomock.Any(), 1, false).Return(&app.Person{Id: 1, Version: 1}, nil)
+
+	hand := PersonHandler{tracing.NewPersonLogic(perLog, provider)}
+
+	r := echo.New()
+	r.HTTPErrorHandler = ErrorHandler
+	r.Use(traceRequests(provider))
+	r.GET("/person/:id", hand.GetPerson)
+
+	req := httptest.NewRequest("GET", "/person/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	logicSpan, serverSpan := spans[0], spans[1]
+
+	require.Equal(t, "GET /person/:id", serverSpan.Name)
+	require.Equal(t, trace.SpanKindServer, serverSpan.SpanKind)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.SpanContext.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", serverSpan.Parent.SpanID().String())
+	require.Contains(t, serverSpan.Attributes, semconv.HTTPStatusCodeKey.Int(200))
+	require.Equal(t, codes.Unset, serverSpan.Status.Code)
+
+	require.Equal(t, "PersonLogic.GetPersonByID", logicSpan.Name)
+	require.Equal(t, serverSpan.SpanContext.SpanID(), logicSpan.Parent.SpanID())
+}
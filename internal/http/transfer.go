@@ -0,0 +1,535 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
+	"github.com/labstack/echo/v4"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MIMETextCSV = "text/csv"
+	MIMENDJSON  = "application/x-ndjson"
+
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	// maxImportErrors limits the number of row errors listed in an import report, all of them are counted.
+	maxImportErrors = 100
+	maxNDJSONLine   = 1 << 20
+)
+
+// maxImportBody limits the size of an imported body, it is a variable for tests to lower it.
+var maxImportBody int64 = 64 << 20
+
+var csvColumns = []string{"id", "email", "phone", "first_name", "last_name", "created_at", "version"}
+
+// importFields maps normalized column names to the JSON names of the imported person fields.
+var importFields = map[string]string{
+	"email":     "email",
+	"phone":     "phone",
+	"firstname": "firstName",
+	"lastname":  "lastName",
+}
+
+type ImportReport struct {
+	DryRun  bool          `json:"dryRun"`
+	Total   int           `json:"total"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Skipped int           `json:"skipped"`
+	Failed  int           `json:"failed"`
+	Errors  []ImportError `json:"errors,omitempty"`
+}
+
+type ImportError struct {
+	Line  int     `json:"line"`
+	Error Problem `json:"error"`
+}
+
+func (r *ImportReport) fail(ctx context.Context, line int, err error) {
+	r.Failed++
+
+	status, body := errorResponse(err)
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(ctx).WithField("line", line).Error(err)
+	}
+
+	if len(r.Errors) < maxImportErrors {
+		r.Errors = append(r.Errors, ImportError{Line: line, Error: problem(status, body)})
+	}
+}
+
+// ExportPersons streams the persons matching the list filters page by page.
+func (ph *PersonHandler) ExportPersons(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = formatCSV
+	}
+
+	if format != formatCSV && format != formatNDJSON {
+		return invalidParam("format", fmt.Errorf("must be %s or %s, got %q", formatCSV, formatNDJSON, format))
+	}
+
+	req, err := listRequest(c)
+	if err != nil {
+		return err
+	}
+
+	req.Limit = app.MaxPageSize
+
+	ctx := c.Request().Context()
+
+	// The first page is loaded before responding, so that bad requests still get an error response.
+	page, err := ph.personLogic.GetPersonList(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	resp := c.Response()
+
+	var w personWriter
+
+	if format == formatCSV {
+		resp.Header().Set(echo.HeaderContentType, MIMETextCSV+"; charset=utf-8")
+		w = newCSVPersonWriter(resp)
+	} else {
+		resp.Header().Set(echo.HeaderContentType, MIMENDJSON)
+		w = newNDJSONPersonWriter(resp)
+	}
+
+	resp.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="persons.%s"`, format))
+	resp.WriteHeader(http.StatusOK)
+
+	for {
+		for i := range page.Persons {
+			if err := w.Write(&page.Persons[i]); err != nil {
+				return err
+			}
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		resp.Flush()
+
+		if page.NextCursor == "" {
+			return nil
+		}
+
+		req.Cursor = page.NextCursor
+
+		page, err = ph.personLogic.GetPersonList(ctx, req)
+		if err != nil {
+			// The response is already sent, the error can only be logged.
+			logging.FromContext(ctx).Errorf("person export aborted: %s", err)
+
+			return err
+		}
+	}
+}
+
+// ImportPersons imports persons from CSV or NDJSON row by row, so a failed row doesn't affect the others.
+func (ph *PersonHandler) ImportPersons(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+
+		switch mediaType {
+		case MIMETextCSV:
+			format = formatCSV
+		case MIMENDJSON, "application/ndjson":
+			format = formatNDJSON
+		default:
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+				fmt.Sprintf("content type must be %s or %s", MIMETextCSV, MIMENDJSON))
+		}
+	}
+
+	dryRun, err := boolParam(c, "dry_run")
+	if err != nil {
+		return err
+	}
+
+	opts := app.ImportOptions{OnDuplicate: app.DuplicateFail, DryRun: dryRun}
+	if dryRun {
+		opts.Batch = app.NewImportBatch()
+	}
+
+	if policy := c.QueryParam("on_duplicate"); policy != "" {
+		opts.OnDuplicate = app.DuplicatePolicy(policy)
+
+		if !opts.OnDuplicate.Valid() {
+			return invalidParam("on_duplicate", fmt.Errorf("must be %s, %s or %s, got %q",
+				app.DuplicateSkip, app.DuplicateUpdate, app.DuplicateFail, policy))
+		}
+	}
+
+	mapping, err := columnMapping(c.QueryParams()["map"])
+	if err != nil {
+		return err
+	}
+
+	body := limitBody(c, maxImportBody)
+
+	var r personReader
+
+	switch format {
+	case formatCSV:
+		r, err = newCSVPersonReader(body, mapping)
+	case formatNDJSON:
+		r = newNDJSONPersonReader(body, mapping)
+	default:
+		return invalidParam("format", fmt.Errorf("must be %s or %s, got %q", formatCSV, formatNDJSON, format))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	report := ImportReport{DryRun: dryRun}
+
+	for {
+		person, line, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil && !errors.Is(err, app.ErrValidation) {
+			return readError(err)
+		}
+
+		report.Total++
+
+		if err != nil {
+			report.fail(ctx, line, err)
+
+			continue
+		}
+
+		if err := c.Validate(&person); err != nil {
+			report.fail(ctx, line, err)
+
+			continue
+		}
+
+		outcome, err := ph.personLogic.ImportPerson(ctx, &person, opts)
+		if errors.Is(err, app.ErrUnavailable) {
+			return err
+		}
+
+		if err != nil {
+			report.fail(ctx, line, err)
+
+			continue
+		}
+
+		switch outcome {
+		case app.ImportCreated:
+			report.Created++
+		case app.ImportUpdated:
+			report.Updated++
+		case app.ImportSkipped:
+			report.Skipped++
+		}
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// columnMapping parses "source:field" pairs, mapping import columns named differently to person fields.
+func columnMapping(pairs []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(pairs))
+
+	for _, pair := range pairs {
+		source, target, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, invalidParam("map", fmt.Errorf("must be source:field, got %q", pair))
+		}
+
+		field, ok := importFields[normalizeColumn(target)]
+		if !ok {
+			return nil, invalidParam("map", fmt.Errorf("unknown person field %q", target))
+		}
+
+		mapping[normalizeColumn(source)] = field
+	}
+
+	return mapping, nil
+}
+
+// personField returns the person field imported from the column, so that "First Name",
+// "first_name" and "firstName" all stand for the same field.
+func personField(column string, mapping map[string]string) (string, bool) {
+	key := normalizeColumn(column)
+
+	if field, ok := mapping[key]; ok {
+		return field, true
+	}
+
+	field, ok := importFields[key]
+
+	return field, ok
+}
+
+// columnReplacer also drops the byte order mark spreadsheets put before the first column.
+var columnReplacer = strings.NewReplacer(" ", "", "_", "", "-", "", "\ufeff", "")
+
+func normalizeColumn(name string) string {
+	return columnReplacer.Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func setPersonField(p *app.Person, field, value string) {
+	value = strings.TrimSpace(value)
+
+	switch field {
+	case "email":
+		p.Email = value
+	case "phone":
+		p.Phone = value
+	case "firstName":
+		p.FirstName = value
+	case "lastName":
+		p.LastName = value
+	}
+}
+
+type personWriter interface {
+	Write(p *app.Person) error
+	Flush() error
+}
+
+type csvPersonWriter struct {
+	w *csv.Writer
+}
+
+func newCSVPersonWriter(out io.Writer) *csvPersonWriter {
+	w := csv.NewWriter(out)
+
+	// Writes are buffered, their errors are reported by Flush.
+	_ = w.Write(csvColumns)
+
+	return &csvPersonWriter{w: w}
+}
+
+// Write neutralizes the cells spreadsheets would take for formulas. Phones are escaped too, as the ones stored
+// before they were normalized may be anything, and E.164 numbers are then kept as text rather than taken for numbers.
+func (w *csvPersonWriter) Write(p *app.Person) error {
+	return w.w.Write([]string{
+		strconv.Itoa(p.Id),
+		escapeFormula(p.Email),
+		escapeFormula(p.Phone),
+		escapeFormula(p.FirstName),
+		escapeFormula(p.LastName),
+		p.CreatedAt.Format(time.RFC3339Nano),
+		strconv.Itoa(p.Version),
+	})
+}
+
+// formulaPrefixes start the cells spreadsheets evaluate as formulas.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula prefixes a cell taken for a formula with a quote, so that it is shown as text.
+// Cells starting with a quote are prefixed too, so that unescapeFormula can tell them from escaped ones.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes+"'", rune(cell[0])) {
+		return "'" + cell
+	}
+
+	return cell
+}
+
+// unescapeFormula reverts escapeFormula, so that exported persons are imported as they were.
+func unescapeFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes+"'", rune(cell[1])) {
+		return cell[1:]
+	}
+
+	return cell
+}
+
+func (w *csvPersonWriter) Flush() error {
+	w.w.Flush()
+
+	return w.w.Error()
+}
+
+type ndjsonPersonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONPersonWriter(out io.Writer) *ndjsonPersonWriter {
+	buf := bufio.NewWriter(out)
+
+	return &ndjsonPersonWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (w *ndjsonPersonWriter) Write(p *app.Person) error {
+	return w.enc.Encode(p)
+}
+
+func (w *ndjsonPersonWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+// limitBody makes reading the request body fail with *http.MaxBytesError after limit bytes.
+func limitBody(c echo.Context, limit int64) io.ReadCloser {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+
+	return req.Body
+}
+
+// readError maps the errors of reading a request body, or an imported one apart from its rows, to the errors of the request.
+func readError(err error) error {
+	var tooLarge *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, bufio.ErrTooLong):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("NDJSON lines must not be longer than %d bytes", maxNDJSONLine)).SetInternal(err)
+	case errors.As(err, &tooLarge):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("request body must not be larger than %d bytes", tooLarge.Limit)).SetInternal(err)
+	}
+
+	return echo.NewHTTPError(http.StatusBadRequest, "can't read request body").SetInternal(err)
+}
+
+// personReader reads imported persons one by one along with their line numbers.
+// Malformed rows are reported with app.ErrValidation and the reading may go on, io.EOF ends it.
+type personReader interface {
+	Read() (app.Person, int, error)
+}
+
+type csvPersonReader struct {
+	r       *csv.Reader
+	columns map[int]string
+}
+
+func newCSVPersonReader(in io.Reader, mapping map[string]string) (*csvPersonReader, error) {
+	r := csv.NewReader(in)
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: CSV header is missing", app.ErrValidation)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed CSV header: %s", app.ErrValidation, err)
+	}
+
+	columns := make(map[int]string, len(header))
+	found := make(map[string]bool, len(importFields))
+
+	for i, name := range header {
+		if field, ok := personField(name, mapping); ok {
+			columns[i] = field
+			found[field] = true
+		}
+	}
+
+	for _, field := range importFields {
+		if !found[field] {
+			return nil, fmt.Errorf("%w: CSV has no column for %s", app.ErrValidation, field)
+		}
+	}
+
+	return &csvPersonReader{r: r, columns: columns}, nil
+}
+
+func (r *csvPersonReader) Read() (app.Person, int, error) {
+	var person app.Person
+
+	record, err := r.r.Read()
+	if err == io.EOF {
+		return person, 0, err
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return person, parseErr.StartLine, fmt.Errorf("%w: malformed CSV row: %s", app.ErrValidation, parseErr.Err)
+	}
+
+	if err != nil {
+		return person, 0, err
+	}
+
+	line, _ := r.r.FieldPos(0)
+
+	for i, field := range r.columns {
+		setPersonField(&person, field, unescapeFormula(record[i]))
+	}
+
+	return person, line, nil
+}
+
+type ndjsonPersonReader struct {
+	s       *bufio.Scanner
+	line    int
+	mapping map[string]string
+}
+
+func newNDJSONPersonReader(in io.Reader, mapping map[string]string) *ndjsonPersonReader {
+	s := bufio.NewScanner(in)
+	s.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+
+	return &ndjsonPersonReader{s: s, mapping: mapping}
+}
+
+func (r *ndjsonPersonReader) Read() (app.Person, int, error) {
+	var person app.Person
+
+	for r.s.Scan() {
+		r.line++
+
+		text := bytes.TrimSpace(r.s.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var doc map[string]json.RawMessage
+
+		if err := json.Unmarshal(text, &doc); err != nil {
+			return person, r.line, fmt.Errorf("%w: line must be a JSON object: %s", app.ErrValidation, err)
+		}
+
+		for key, raw := range doc {
+			field, ok := personField(key, r.mapping)
+			if !ok {
+				continue
+			}
+
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return person, r.line, fmt.Errorf("%w: field %s must be a string", app.ErrValidation, key)
+			}
+
+			setPersonField(&person, field, value)
+		}
+
+		return person, r.line, nil
+	}
+
+	if err := r.s.Err(); err != nil {
+		return person, r.line, err
+	}
+
+	return person, r.line, io.EOF
+}
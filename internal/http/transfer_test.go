@@ -0,0 +1,261 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPersonHandler_ExportPersons(t *testing.T) {
+	first := app.Person{Id: 1, Email: "a@gmail.com", Phone: "+1111111", FirstName: "Ann", LastName: "Lee", CreatedAt: createdAt, Version: 1}
+	second := app.Person{Id: 2, Email: "b@gmail.com", Phone: "+2222222", FirstName: "Bob", LastName: "Ray, Jr.", CreatedAt: createdAt, Version: 3}
+	formula := app.Person{Id: 3, Email: "-c@gmail.com", Phone: "+3333333", FirstName: "=HYPERLINK(\"x\")", LastName: "@Cid", CreatedAt: createdAt, Version: 1}
+	// Phones stored before they were normalized may be anything.
+	legacy := app.Person{Id: 4, Email: "d@gmail.com", Phone: "=HYPERLINK(\"y\")", FirstName: "Dan", LastName: "Fox", CreatedAt: createdAt, Version: 1}
+
+	testTable := []struct {
+		name                string
+		query               string
+		expectedStatusCode  int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "CSV",
+			expectedStatusCode:  200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody: "id,email,phone,first_name,last_name,created_at,version\n" +
+				"1,a@gmail.com,'+1111111,Ann,Lee,2022-11-01T12:00:00Z,1\n" +
+				"2,b@gmail.com,'+2222222,Bob,\"Ray, Jr.\",2022-11-01T12:00:00Z,3\n" +
+				"3,'-c@gmail.com,'+3333333,\"'=HYPERLINK(\"\"x\"\")\",'@Cid,2022-11-01T12:00:00Z,1\n" +
+				"4,d@gmail.com,\"'=HYPERLINK(\"\"y\"\")\",Dan,Fox,2022-11-01T12:00:00Z,1",
+		}, {
+			name:                "NDJSON",
+			query:               "?format=ndjson",
+			expectedStatusCode:  200,
+			expectedContentType: MIMENDJSON,
+			expectedBody: `{"id":1,"email":"a@gmail.com","phone":"+1111111","firstName":"Ann","lastName":"Lee","createdAt":"2022-11-01T12:00:00Z","version":1}` + "\n" +
+				`{"id":2,"email":"b@gmail.com","phone":"+2222222","firstName":"Bob","lastName":"Ray, Jr.","createdAt":"2022-11-01T12:00:00Z","version":3}` + "\n" +
+				`{"id":3,"email":"-c@gmail.com","phone":"+3333333","firstName":"=HYPERLINK(\"x\")","lastName":"@Cid","createdAt":"2022-11-01T12:00:00Z","version":1}` + "\n" +
+				`{"id":4,"email":"d@gmail.com","phone":"=HYPERLINK(\"y\")","firstName":"Dan","lastName":"Fox","createdAt":"2022-11-01T12:00:00Z","version":1}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			req := app.ListRequest{Limit: app.MaxPageSize}
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			gomock.InOrder(
+				perLog.EXPECT().GetPersonList(ctx, req).Return(&app.PersonPage{Persons: []app.Person{first}, NextCursor: "next"}, nil),
+				perLog.EXPECT().GetPersonList(ctx, app.ListRequest{Limit: app.MaxPageSize, Cursor: "next"}).
+					Return(&app.PersonPage{Persons: []app.Person{second, formula, legacy}}, nil),
+			)
+
+			hand := PersonHandler{perLog}
+
+			rec := httptest.NewRecorder()
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
+			r.GET("/person/export", hand.ExportPersons)
+
+			r.ServeHTTP(rec, httptest.NewRequest("GET", "/person/export"+testCase.query, nil))
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedContentType, rec.Header().Get(echo.HeaderContentType))
+			require.Equal(t, testCase.expectedBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+}
+
+func TestPersonHandler_ExportPersons_UnknownFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hand := PersonHandler{mock_app.NewMockPersonLogic(ctrl)}
+
+	rec := httptest.NewRecorder()
+
+	r := echo.New()
+	r.HTTPErrorHandler = ErrorHandler
+	r.Validator = NewValidator("")
+	r.GET("/person/export", hand.ExportPersons)
+
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/person/export?format=xml", nil))
+
+	assert.Equal(t, 400, rec.Code)
+}
+
+func TestPersonHandler_ImportPersons(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonLogic, ctx context.Context)
+
+	ann := app.Person{Email: "a@gmail.com", Phone: "+14155550101", FirstName: "Ann", LastName: "Lee"}
+	bob := app.Person{Email: "b@gmail.com", Phone: "+14155550102", FirstName: "Bob", LastName: "Ray"}
+	opts := app.ImportOptions{OnDuplicate: app.DuplicateFail}
+
+	testTable := []struct {
+		name        string
+		query       string
+		contentType string
+		inputBody   string
+		mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:        "CSV With Mapping",
+			query:       "?map=Mail:email&map=Given:firstName",
+			contentType: "text/csv",
+			inputBody:   "Mail,Phone,Given,Last Name,Notes\na@gmail.com,+14155550101,Ann,Lee,x\nb@gmail.com,+14155550102,Bob\nc@gmail.com,,Cid,Fox,\nb@gmail.com,+14155550102,Bob,Ray,\n",
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().ImportPerson(ctx, &ann, opts).Return(app.ImportCreated, nil)
+				s.EXPECT().ImportPerson(ctx, &bob, opts).
+					Return(app.ImportOutcome(""), fmt.Errorf("%w: another person with email address b@gmail.com already exists", app.ErrEmailConflict))
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"dryRun":false,"total":4,"created":1,"updated":0,"skipped":0,"failed":3,"errors":[` +
+				`{"line":3,"error":{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: malformed CSV row: wrong number of fields","code":"validation_error"}},` +
+				`{"line":4,"error":{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: phone is a required field","code":"validation_error",` +
+				`"errors":[{"field":"phone","rule":"required","message":"phone is a required field"}]}},` +
+				`{"line":5,"error":{"type":"about:blank","title":"Conflict","status":409,"detail":"email address is already in use: another person with email address b@gmail.com already exists","code":"email_conflict"}}]}`,
+		}, {
+			name:        "NDJSON Dry Run",
+			query:       "?dry_run=true&on_duplicate=update",
+			contentType: MIMENDJSON,
+			inputBody:   `{"email":"a@gmail.com","phone":"+14155550101","first_name":"Ann","lastName":"Lee","age":30}` + "\n\n" + `{"email":1}` + "\n",
+			mockBehavior: func(s *mock_app.MockPersonLogic, ctx context.Context) {
+				s.EXPECT().ImportPerson(ctx, &ann, app.ImportOptions{OnDuplicate: app.DuplicateUpdate, DryRun: true, Batch: app.NewImportBatch()}).Return(app.ImportUpdated, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"dryRun":true,"total":2,"created":0,"updated":1,"skipped":0,"failed":1,"errors":[` +
+				`{"line":3,"error":{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: field email must be a string","code":"validation_error"}}]}`,
+		}, {
+			name:                "Missing Column",
+			contentType:         "text/csv",
+			inputBody:           "email,phone,first_name\na@gmail.com,+14155550101,Ann\n",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: CSV has no column for lastName","code":"validation_error"}`,
+		}, {
+			name:                "Unknown Policy",
+			query:               "?on_duplicate=merge",
+			contentType:         "text/csv",
+			inputBody:           "email,phone,first_name,last_name\n",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request data: invalid parameter on_duplicate: must be skip, update or fail, got \"merge\"","code":"validation_error"}`,
+		}, {
+			name:                "Line Too Long",
+			contentType:         MIMENDJSON,
+			inputBody:           `{"email":"` + strings.Repeat("a", maxNDJSONLine) + `"}`,
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  413,
+			expectedRequestBody: `{"error":{"code":"request_entity_too_large","message":"NDJSON lines must not be longer than 1048576 bytes"}}`,
+		}, {
+			name:                "Unsupported Content Type",
+			contentType:         "application/xml",
+			inputBody:           "<persons/>",
+			mockBehavior:        func(s *mock_app.MockPersonLogic, ctx context.Context) {},
+			expectedStatusCode:  415,
+			expectedRequestBody: `{"error":{"code":"unsupported_media_type","message":"content type must be text/csv or application/x-ndjson"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			perLog := mock_app.NewMockPersonLogic(ctrl)
+			testCase.mockBehavior(perLog, context.Background())
+
+			hand := PersonHandler{perLog}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/person/import"+testCase.query, bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, testCase.contentType)
+
+			r := echo.New()
+			r.HTTPErrorHandler = ErrorHandler
+			r.Validator = NewValidator("")
+			r.POST("/person/import", hand.ImportPersons)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			require.Equal(t, testCase.expectedRequestBody, strings.TrimRight(rec.Body.String(), "\n"))
+		})
+	}
+}
+
+func TestPersonHandler_ImportPersons_ReadError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hand := PersonHandler{mock_app.NewMockPersonLogic(ctrl)}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/person/import", iotest.ErrReader(errors.New("connection reset")))
+	req.Header.Set(echo.HeaderContentType, MIMENDJSON)
+
+	r := echo.New()
+	r.HTTPErrorHandler = ErrorHandler
+	r.POST("/person/import", hand.ImportPersons)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, 400, rec.Code)
+	require.Equal(t, `{"error":{"code":"bad_request","message":"can't read request body"}}`, strings.TrimRight(rec.Body.String(), "\n"))
+}
+
+func TestPersonHandler_ImportPersons_BodyTooLarge(t *testing.T) {
+	defer func(limit int64) { maxImportBody = limit }(maxImportBody)
+	maxImportBody = 64
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hand := PersonHandler{mock_app.NewMockPersonLogic(ctrl)}
+
+	body := `{"email":"a@gmail.com","phone":"+14155550101","firstName":"Ann","lastName":"` + strings.Repeat("a", 64) + `"}` + "\n"
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/person/import", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, MIMENDJSON)
+
+	r := echo.New()
+	r.HTTPErrorHandler = ErrorHandler
+	r.Validator = NewValidator("")
+	r.POST("/person/import", hand.ImportPersons)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, 413, rec.Code)
+	require.Equal(t, `{"error":{"code":"request_entity_too_large","message":"request body must not be larger than 64 bytes"}}`, strings.TrimRight(rec.Body.String(), "\n"))
+}
+
+func TestEscapeFormula(t *testing.T) {
+	for _, cell := range []string{"", "Ann", "=SUM(A1)", "+1", "-1", "@x", "'=x", "'"} {
+		require.Equal(t, cell, unescapeFormula(escapeFormula(cell)), "exported cells must be imported as they were")
+	}
+
+	require.Equal(t, "'=SUM(A1)", escapeFormula("=SUM(A1)"))
+	require.Equal(t, "O'Neil", escapeFormula("O'Neil"))
+}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	"reflect"
+	"strings"
+)
+
+// Validator is the echo.Validator checking request bodies by their validate tags. It is shared by all requests,
+// so that validator caches the rules of every type once. Broken rules are reported by *app.ValidationError.
+type Validator struct {
+	validate *validator.Validate
+	trans    ut.Translator
+}
+
+// Partial makes Validator check only the given fields of Struct, they are named as in Go.
+type Partial struct {
+	Struct interface{}
+	Fields []string
+}
+
+// customRules are the project rules used in validate tags along with their messages.
+// They are checked as the values are normalized by the logic, so that both agree on what is valid.
+func customRules(phoneRegion string) []struct {
+	tag     string
+	message string
+	valid   func(value string) bool
+} {
+	return []struct {
+		tag     string
+		message string
+		valid   func(value string) bool
+	}{
+		{
+			// It isn't the built-in e164, as phones without a country code are valid when there is a region.
+			tag:     "phone",
+			message: "{0} must be a valid phone number",
+			valid: func(value string) bool {
+				_, err := app.NormalizePhone(value, phoneRegion)
+
+				return err == nil
+			},
+		}, {
+			tag:     "person_name",
+			message: fmt.Sprintf("{0} must be a name of at most %d characters without control characters", app.MaxNameLength),
+			valid: func(value string) bool {
+				_, err := app.NormalizeName(value)
+
+				return err == nil
+			},
+		}, {
+			tag:     "not_disposable_email",
+			message: "{0} must not be a disposable email address",
+			valid: func(value string) bool {
+				return !app.IsDisposableEmail(value)
+			},
+		},
+	}
+}
+
+// NewValidator returns the validator of request bodies, phone numbers without a country code
+// are taken as the ones of phoneRegion.
+func NewValidator(phoneRegion string) *Validator {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(jsonName)
+
+	english := en.New()
+	trans, _ := ut.New(english, english).GetTranslator("en")
+
+	// The rules and their messages are fixed, registering them fails on bugs alone.
+	if err := entranslations.RegisterDefaultTranslations(validate, trans); err != nil {
+		panic(err)
+	}
+
+	for _, rule := range customRules(phoneRegion) {
+		rule := rule
+
+		err := validate.RegisterValidation(rule.tag, func(fl validator.FieldLevel) bool {
+			return rule.valid(fl.Field().String())
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		err = validate.RegisterTranslation(rule.tag, trans,
+			func(ut ut.Translator) error {
+				return ut.Add(rule.tag, rule.message, true)
+			},
+			func(ut ut.Translator, fe validator.FieldError) string {
+				message, _ := ut.T(rule.tag, fe.Field())
+
+				return message
+			},
+		)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return &Validator{validate: validate, trans: trans}
+}
+
+// Validate checks the struct with the surrounding spaces of its strings trimmed, as the logic trims them too.
+// The struct itself isn't modified.
+func (v *Validator) Validate(i interface{}) error {
+	var err error
+
+	if partial, ok := i.(Partial); ok {
+		err = v.validate.StructPartial(trimmed(partial.Struct), partial.Fields...)
+	} else {
+		err = v.validate.Struct(trimmed(i))
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	var verr app.ValidationError
+
+	for _, fe := range errs {
+		verr.Add(fe.Field(), fe.Tag(), fe.Translate(v.trans))
+	}
+
+	return &verr
+}
+
+// trimmed returns a copy of the struct i points to with the spaces around its strings and the strings
+// its fields point to trimmed. Anything else is returned as it is.
+func trimmed(i interface{}) interface{} {
+	ptr := reflect.ValueOf(i)
+	if ptr.Kind() != reflect.Pointer || ptr.Elem().Kind() != reflect.Struct {
+		return i
+	}
+
+	copied := reflect.New(ptr.Elem().Type())
+	copied.Elem().Set(ptr.Elem())
+
+	for i := 0; i < copied.Elem().NumField(); i++ {
+		field := copied.Elem().Field(i)
+
+		switch {
+		case !field.CanSet():
+		case field.Kind() == reflect.String:
+			field.SetString(strings.TrimSpace(field.String()))
+		case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.String && !field.IsNil():
+			value := reflect.New(field.Type().Elem())
+			value.Elem().SetString(strings.TrimSpace(field.Elem().String()))
+			field.Set(value)
+		}
+	}
+
+	return copied.Interface()
+}
+
+// jsonName names fields in validation errors as they are named in requests.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+
+	return name
+}
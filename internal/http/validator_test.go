@@ -0,0 +1,96 @@
+package http
+
+import (
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestValidator_Validate(t *testing.T) {
+	v := NewValidator("")
+
+	err := v.Validate(&app.Person{Email: "test@gmail.com", LastName: "Test"})
+	require.Equal(t, &app.ValidationError{Fields: []app.FieldError{
+		{Field: "phone", Rule: "required", Message: "phone is a required field"},
+		{Field: "firstName", Rule: "required", Message: "firstName is a required field"},
+	}}, err)
+	require.ErrorIs(t, err, app.ErrValidation)
+
+	require.NoError(t, v.Validate(Partial{Struct: &app.Person{Email: "test@gmail.com"}, Fields: []string{"Email"}}))
+
+	err = v.Validate(Partial{Struct: &app.Person{Email: "test@gmail.com"}, Fields: []string{"Email", "Phone"}})
+	require.Equal(t, &app.ValidationError{Fields: []app.FieldError{
+		{Field: "phone", Rule: "required", Message: "phone is a required field"},
+	}}, err)
+}
+
+func TestValidator_CustomRules(t *testing.T) {
+	valid := app.Person{Email: "test@gmail.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"}
+
+	testTable := []struct {
+		name        string
+		region      string
+		modify      func(p *app.Person)
+		expectedErr error
+	}{
+		{
+			name:   "Valid",
+			modify: func(p *app.Person) {},
+		}, {
+			name:   "National Phone With Region",
+			region: "US",
+			modify: func(p *app.Person) { p.Phone = "(415) 555-0101" },
+		}, {
+			name:   "National Phone Without Region",
+			modify: func(p *app.Person) { p.Phone = "(415) 555-0101" },
+			expectedErr: &app.ValidationError{Fields: []app.FieldError{
+				{Field: "phone", Rule: "phone", Message: "phone must be a valid phone number"},
+			}},
+		}, {
+			name: "Surrounding Spaces",
+			modify: func(p *app.Person) {
+				p.Email = " John@Example.com "
+				p.Phone = "\t+14155550101 "
+				p.FirstName = " John"
+			},
+		}, {
+			name:   "Spaces Only",
+			modify: func(p *app.Person) { p.LastName = "  " },
+			expectedErr: &app.ValidationError{Fields: []app.FieldError{
+				{Field: "lastName", Rule: "required", Message: "lastName is a required field"},
+			}},
+		}, {
+			name:   "Invalid Name",
+			modify: func(p *app.Person) { p.FirstName = "John\x00" },
+			expectedErr: &app.ValidationError{Fields: []app.FieldError{
+				{Field: "firstName", Rule: "person_name", Message: "firstName must be a name of at most 100 characters without control characters"},
+			}},
+		}, {
+			name:   "Disposable Email",
+			modify: func(p *app.Person) { p.Email = "test@eu.mailinator.com" },
+			expectedErr: &app.ValidationError{Fields: []app.FieldError{
+				{Field: "email", Rule: "not_disposable_email", Message: "email must not be a disposable email address"},
+			}},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			person := valid
+			testCase.modify(&person)
+
+			before := person
+
+			err := NewValidator(testCase.region).Validate(&person)
+			require.Equal(t, before, person, "validation must not modify the person")
+
+			if testCase.expectedErr == nil {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Equal(t, testCase.expectedErr, err)
+		})
+	}
+}
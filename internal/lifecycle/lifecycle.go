@@ -0,0 +1,134 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// Component is a part of the service that runs until it is stopped, such as a server or a background worker.
+type Component interface {
+	// Start runs the component and may block until it is stopped, an error returned meanwhile shuts the service down.
+	Start() error
+	// Stop makes Start return and releases the resources of the component, giving up when ctx is done.
+	Stop(ctx context.Context) error
+}
+
+// Manager starts components all at once, as their Start may block, so none of them may expect another one
+// to be started. It stops them in reverse order of adding, so that each component is stopped before the ones
+// it depends on.
+type Manager struct {
+	components []namedComponent
+}
+
+type namedComponent struct {
+	name string
+	Component
+}
+
+func New() *Manager {
+	return &Manager{}
+}
+
+func (m *Manager) Add(name string, c Component) {
+	m.components = append(m.components, namedComponent{name: name, Component: c})
+}
+
+// Run starts the components concurrently and blocks until ctx is done or one of them fails, then stops all of them
+// within timeout. The failure of a component is returned, otherwise the first error of stopping them.
+func (m *Manager) Run(ctx context.Context, timeout time.Duration) error {
+	failed := make(chan error, len(m.components))
+
+	for _, c := range m.components {
+		c := c
+
+		go func() {
+			if err := c.Start(); err != nil {
+				failed <- fmt.Errorf("%s failed: %w", c.name, err)
+			}
+		}()
+	}
+
+	var err error
+
+	select {
+	case <-ctx.Done():
+		logrus.Infof("shutting down within %s", timeout)
+	case err = <-failed:
+		logrus.Errorf("shutting down: %s", err)
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for i := len(m.components) - 1; i >= 0; i-- {
+		c := m.components[i]
+
+		if stopErr := c.Stop(stopCtx); stopErr != nil {
+			logrus.Errorf("stopping %s failed: %s", c.name, stopErr)
+
+			if err == nil {
+				err = fmt.Errorf("stopping %s failed: %w", c.name, stopErr)
+			}
+		}
+	}
+
+	return err
+}
+
+// Func makes a component of plain functions, either of them may be nil.
+type Func struct {
+	OnStart func() error
+	OnStop  func(ctx context.Context) error
+}
+
+func (f Func) Start() error {
+	if f.OnStart == nil {
+		return nil
+	}
+
+	return f.OnStart()
+}
+
+func (f Func) Stop(ctx context.Context) error {
+	if f.OnStop == nil {
+		return nil
+	}
+
+	return f.OnStop(ctx)
+}
+
+// Worker runs a background function until it is stopped, the function must return once its context is done.
+type Worker struct {
+	run    func(ctx context.Context)
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewWorker(run func(ctx context.Context)) *Worker {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Worker{run: run, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+}
+
+func (w *Worker) Start() error {
+	defer close(w.done)
+
+	w.run(w.ctx)
+
+	return nil
+}
+
+// Stop cancels the context of the function and waits for it to return.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.cancel()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,73 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestManager_Run(t *testing.T) {
+	var stopped []string
+
+	stopper := func(name string) Func {
+		return Func{OnStop: func(ctx context.Context) error {
+			stopped = append(stopped, name)
+
+			return nil
+		}}
+	}
+
+	t.Run("Stops In Reverse Order", func(t *testing.T) {
+		stopped = nil
+
+		worker := NewWorker(func(ctx context.Context) {
+			<-ctx.Done()
+			stopped = append(stopped, "worker")
+		})
+
+		m := New()
+		m.Add("db", stopper("db"))
+		m.Add("worker", worker)
+		m.Add("server", stopper("server"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.NoError(t, m.Run(ctx, time.Second))
+		require.Equal(t, []string{"server", "worker", "db"}, stopped)
+	})
+
+	t.Run("Component Fails", func(t *testing.T) {
+		stopped = nil
+
+		failure := errors.New("address in use")
+
+		m := New()
+		m.Add("db", stopper("db"))
+		m.Add("server", Func{OnStart: func() error { return failure }})
+
+		err := m.Run(context.Background(), time.Second)
+		require.ErrorIs(t, err, failure)
+		require.Equal(t, []string{"db"}, stopped)
+	})
+
+	t.Run("Stop Deadline", func(t *testing.T) {
+		stopped = nil
+
+		block := make(chan struct{})
+		defer close(block)
+
+		m := New()
+		m.Add("db", stopper("db"))
+		m.Add("worker", NewWorker(func(ctx context.Context) { <-block }))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := m.Run(ctx, 10*time.Millisecond)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, []string{"db"}, stopped, "components must be stopped after the deadline anyway")
+	})
+}
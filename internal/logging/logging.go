@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// Formats of the log output.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Setup configures the standard logger every logger of the service derives from.
+func Setup(level, format string) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	case FormatText:
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	logrus.SetLevel(lvl)
+
+	return nil
+}
+
+// scope holds the logger of a request, fields learned while handling it are added to the logger in place,
+// so that the context of the request doesn't need to be replaced.
+type scope struct {
+	mu     sync.Mutex
+	logger *logrus.Entry
+}
+
+type scopeKey struct{}
+
+// NewContext attaches the logger to ctx, its entries are meant to carry the fields of the request.
+func NewContext(ctx context.Context, logger *logrus.Entry) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{logger: logger})
+}
+
+// FromContext returns the logger attached to ctx or the standard one if there is none.
+func FromContext(ctx context.Context) *logrus.Entry {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return logrus.NewEntry(logrus.StandardLogger())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.logger
+}
+
+// AddFields adds the fields to the logger attached to ctx, it does nothing if there is none.
+func AddFields(ctx context.Context, fields logrus.Fields) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logger = s.logger.WithFields(fields)
+}
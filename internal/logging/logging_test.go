@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestContextLogger(t *testing.T) {
+	ctx := context.Background()
+
+	require.Empty(t, FromContext(ctx).Data)
+	AddFields(ctx, logrus.Fields{"person_id": 1})
+	require.Empty(t, FromContext(ctx).Data, "fields must not be added without a logger in the context")
+
+	ctx = NewContext(ctx, logrus.WithField("request_id", "req-1"))
+	AddFields(ctx, logrus.Fields{"person_id": 1})
+
+	require.Equal(t, logrus.Fields{"request_id": "req-1", "person_id": 1}, FromContext(ctx).Data)
+}
+
+func TestSetup(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+	defer logrus.SetFormatter(logrus.StandardLogger().Formatter)
+
+	require.NoError(t, Setup("debug", FormatJSON))
+	require.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	require.IsType(t, &logrus.JSONFormatter{}, logrus.StandardLogger().Formatter)
+
+	require.Error(t, Setup("verbose", FormatJSON))
+	require.EqualError(t, Setup("info", "xml"), `unknown log format "xml"`)
+}
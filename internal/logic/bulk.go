@@ -0,0 +1,296 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+)
+
+func (p *PerLogic) StorePersons(ctx context.Context, persons []app.Person, mode app.BulkMode) ([]app.BulkResult, error) {
+	if err := app.CheckBulk(len(persons), mode); err != nil {
+		return nil, err
+	}
+
+	if mode == app.BulkPerItem {
+		return perItem(len(persons), func(i int) (*app.Person, error) {
+			per := persons[i]
+
+			return &per, p.StorePerson(ctx, &per)
+		}), nil
+	}
+
+	normalized, err := p.normalizePersons(persons)
+	if err != nil {
+		return atomicResults(len(persons), nil, err)
+	}
+
+	persons = normalized
+
+	emails := make([]string, len(persons))
+	for i := range persons {
+		emails[i] = persons[i].Email
+	}
+
+	if err := duplicate(emails, func(email string) error {
+		return fmt.Errorf("%w: email address %s is used by another item", app.ErrEmailConflict, email)
+	}); err != nil {
+		return atomicResults(len(persons), nil, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+
+	stored := make([]app.Person, len(persons))
+
+	err = p.perRepo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		copy(stored, persons)
+
+		if err := repo.StoreBatch(ctx, stored); err != nil {
+			return err
+		}
+
+		recs := make([]*app.AuditRecord, len(stored))
+
+		for i := range stored {
+			rec, err := auditRecord(ctx, app.ActionCreate, nil, &stored[i])
+			if err != nil {
+				return err
+			}
+
+			recs[i] = rec
+		}
+
+		return repo.AddAudit(ctx, recs...)
+	})
+
+	return atomicResults(len(persons), stored, err)
+}
+
+func (p *PerLogic) UpdatePersons(ctx context.Context, persons []app.Person, mode app.BulkMode) ([]app.BulkResult, error) {
+	if err := app.CheckBulk(len(persons), mode); err != nil {
+		return nil, err
+	}
+
+	if mode == app.BulkPerItem {
+		return perItem(len(persons), func(i int) (*app.Person, error) {
+			per := persons[i]
+
+			return &per, p.UpdatePerson(ctx, &per)
+		}), nil
+	}
+
+	normalized, err := p.normalizePersons(persons)
+	if err != nil {
+		return atomicResults(len(persons), nil, err)
+	}
+
+	persons = normalized
+
+	ids := make([]int, len(persons))
+	emails := make([]string, len(persons))
+
+	for i := range persons {
+		ids[i] = persons[i].Id
+		emails[i] = persons[i].Email
+	}
+
+	if err := duplicate(ids, func(id int) error {
+		return fmt.Errorf("%w: person with ID %d is updated by another item", app.ErrValidation, id)
+	}); err != nil {
+		return atomicResults(len(persons), nil, err)
+	}
+
+	if err := duplicate(emails, func(email string) error {
+		return fmt.Errorf("%w: email address %s is used by another item", app.ErrEmailConflict, email)
+	}); err != nil {
+		return atomicResults(len(persons), nil, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+
+	updated := make([]app.Person, len(persons))
+
+	err = p.perRepo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		copy(updated, persons)
+
+		before, err := currentVersions(ctx, repo, ids, func(i int) int { return persons[i].Version })
+		if err != nil {
+			return err
+		}
+
+		if err := repo.UpdateBatch(ctx, updated); err != nil {
+			return err
+		}
+
+		recs := make([]*app.AuditRecord, len(updated))
+
+		for i := range updated {
+			rec, err := auditRecord(ctx, app.ActionUpdate, before[i], &updated[i])
+			if err != nil {
+				return err
+			}
+
+			recs[i] = rec
+		}
+
+		return repo.AddAudit(ctx, recs...)
+	})
+
+	return atomicResults(len(persons), updated, err)
+}
+
+func (p *PerLogic) DeletePersons(ctx context.Context, refs []app.PersonRef, mode app.BulkMode) ([]app.BulkResult, error) {
+	if err := app.CheckBulk(len(refs), mode); err != nil {
+		return nil, err
+	}
+
+	if mode == app.BulkPerItem {
+		return perItem(len(refs), func(i int) (*app.Person, error) {
+			return nil, p.DeletePerson(ctx, refs[i].ID, refs[i].Version)
+		}), nil
+	}
+
+	ids := make([]int, len(refs))
+	for i := range refs {
+		ids[i] = refs[i].ID
+	}
+
+	if err := duplicate(ids, func(id int) error {
+		return fmt.Errorf("%w: person with ID %d is deleted by another item", app.ErrValidation, id)
+	}); err != nil {
+		return atomicResults(len(refs), nil, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+
+	err := p.perRepo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		before, err := currentVersions(ctx, repo, ids, func(i int) int { return refs[i].Version })
+		if err != nil {
+			return err
+		}
+
+		if err := repo.DeleteBatch(ctx, refs); err != nil {
+			return err
+		}
+
+		deleted, err := repo.GetByIDs(ctx, ids, true)
+		if err != nil {
+			return err
+		}
+
+		after := make(map[int]*app.Person, len(deleted))
+		for i := range deleted {
+			after[deleted[i].Id] = &deleted[i]
+		}
+
+		recs := make([]*app.AuditRecord, len(ids))
+
+		for i, id := range ids {
+			rec, err := auditRecord(ctx, app.ActionDelete, before[i], after[id])
+			if err != nil {
+				return err
+			}
+
+			recs[i] = rec
+		}
+
+		return repo.AddAudit(ctx, recs...)
+	})
+
+	return atomicResults(len(refs), nil, err)
+}
+
+// currentVersions loads the live persons with the given IDs in the order of the IDs
+// and checks that they have the versions the items are based on.
+func currentVersions(ctx context.Context, repo app.PersonRepository, ids []int, version func(i int) int) ([]*app.Person, error) {
+	persons, err := repo.GetByIDs(ctx, ids, false)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*app.Person, len(persons))
+	for i := range persons {
+		byID[persons[i].Id] = &persons[i]
+	}
+
+	current := make([]*app.Person, len(ids))
+
+	for i, id := range ids {
+		person, ok := byID[id]
+
+		switch {
+		case !ok:
+			return nil, &app.BatchItemError{Index: i, Err: fmt.Errorf("%w: person with ID %d doesn't exist", app.ErrNotFound, id)}
+		case person.Version != version(i):
+			return nil, &app.BatchItemError{
+				Index: i,
+				Err:   fmt.Errorf("%w: expected version %d, current is %d", app.ErrVersionMismatch, version(i), person.Version),
+			}
+		}
+
+		current[i] = person
+	}
+
+	return current, nil
+}
+
+// duplicate reports the first item repeating the value of a previous one.
+func duplicate[T comparable](values []T, duplicateErr func(value T) error) error {
+	seen := make(map[T]bool, len(values))
+
+	for i, value := range values {
+		if seen[value] {
+			return &app.BatchItemError{Index: i, Err: duplicateErr(value)}
+		}
+
+		seen[value] = true
+	}
+
+	return nil
+}
+
+// perItem applies every item separately and collects their results.
+func perItem(n int, apply func(i int) (*app.Person, error)) []app.BulkResult {
+	results := make([]app.BulkResult, n)
+
+	for i := range results {
+		person, err := apply(i)
+		if err != nil {
+			results[i].Err = err
+
+			continue
+		}
+
+		results[i].Person = person
+	}
+
+	return results
+}
+
+// atomicResults reports the outcome of an atomic bulk request. When a single item has failed
+// the request, the others are reported as aborted, any other failure fails the whole request.
+func atomicResults(n int, applied []app.Person, err error) ([]app.BulkResult, error) {
+	results := make([]app.BulkResult, n)
+
+	var itemErr *app.BatchItemError
+
+	switch {
+	case err == nil:
+		for i := range applied {
+			results[i].Person = &applied[i]
+		}
+	case errors.As(err, &itemErr):
+		for i := range results {
+			results[i].Err = app.ErrBulkAborted
+		}
+
+		results[itemErr.Index].Err = itemErr.Err
+	default:
+		return nil, err
+	}
+
+	return results, nil
+}
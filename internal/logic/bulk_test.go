@@ -0,0 +1,137 @@
+package logic
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/memory"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPerLogic_StorePersons(t *testing.T) {
+	testTable := []struct {
+		name         string
+		mode         app.BulkMode
+		emails       []string
+		expectedErrs []error
+		expectedLen  int
+	}{
+		{
+			name:         "Atomic",
+			mode:         app.BulkAtomic,
+			emails:       []string{"a@example.com", "b@example.com"},
+			expectedErrs: []error{nil, nil},
+			expectedLen:  3,
+		}, {
+			name:         "Atomic Conflict",
+			mode:         app.BulkAtomic,
+			emails:       []string{"a@example.com", "taken@example.com"},
+			expectedErrs: []error{app.ErrBulkAborted, app.ErrEmailConflict},
+			expectedLen:  1,
+		}, {
+			name:         "Atomic Duplicate",
+			mode:         app.BulkAtomic,
+			emails:       []string{"a@example.com", "a@example.com"},
+			expectedErrs: []error{app.ErrBulkAborted, app.ErrEmailConflict},
+			expectedLen:  1,
+		}, {
+			name:         "Per Item Conflict",
+			mode:         app.BulkPerItem,
+			emails:       []string{"a@example.com", "taken@example.com", "a@example.com"},
+			expectedErrs: []error{nil, app.ErrEmailConflict, app.ErrEmailConflict},
+			expectedLen:  2,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := memory.NewPersonRepo()
+			logic := NewPersonLogic(repo, time.Second, "")
+			ctx := context.Background()
+
+			require.NoError(t, logic.StorePerson(ctx, &app.Person{Email: "taken@example.com", Phone: "+14155550101", FirstName: "Test", LastName: "Test"}))
+
+			persons := make([]app.Person, 0, len(testCase.emails))
+			for _, email := range testCase.emails {
+				persons = append(persons, app.Person{Email: email, Phone: "+14155550101", FirstName: "Test", LastName: "Test"})
+			}
+
+			results, err := logic.StorePersons(ctx, persons, testCase.mode)
+			require.NoError(t, err)
+			require.Len(t, results, len(testCase.expectedErrs))
+
+			for i, res := range results {
+				if testCase.expectedErrs[i] != nil {
+					require.ErrorIs(t, res.Err, testCase.expectedErrs[i])
+					require.Nil(t, res.Person)
+
+					continue
+				}
+
+				require.NoError(t, res.Err)
+				require.NotZero(t, res.Person.Id)
+			}
+
+			page, err := logic.GetPersonList(ctx, app.ListRequest{})
+			require.NoError(t, err)
+			require.Len(t, page.Persons, testCase.expectedLen)
+		})
+	}
+}
+
+func TestPerLogic_UpdateAndDeletePersons(t *testing.T) {
+	repo := memory.NewPersonRepo()
+	logic := NewPersonLogic(repo, time.Second, "")
+	ctx := context.Background()
+
+	results, err := logic.StorePersons(ctx, []app.Person{
+		{Email: "a@example.com", Phone: "+14155550101", FirstName: "Test", LastName: "A"},
+		{Email: "b@example.com", Phone: "+14155550102", FirstName: "Test", LastName: "B"},
+	}, app.BulkAtomic)
+	require.NoError(t, err)
+
+	a, b := *results[0].Person, *results[1].Person
+
+	stale := b
+	stale.Version++
+	a.LastName = "Updated"
+
+	results, err = logic.UpdatePersons(ctx, []app.Person{a, stale}, app.BulkAtomic)
+	require.NoError(t, err)
+	require.ErrorIs(t, results[0].Err, app.ErrBulkAborted)
+	require.ErrorIs(t, results[1].Err, app.ErrVersionMismatch)
+
+	unchanged, err := logic.GetPersonByID(ctx, a.Id, false)
+	require.NoError(t, err)
+	require.Equal(t, "A", unchanged.LastName)
+
+	results, err = logic.UpdatePersons(ctx, []app.Person{a, stale}, app.BulkPerItem)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	require.Equal(t, "Updated", results[0].Person.LastName)
+	require.ErrorIs(t, results[1].Err, app.ErrVersionMismatch)
+
+	results, err = logic.DeletePersons(ctx, []app.PersonRef{
+		{ID: a.Id, Version: a.Version + 1},
+		{ID: b.Id, Version: b.Version},
+	}, app.BulkAtomic)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	require.NoError(t, results[1].Err)
+
+	page, err := logic.GetPersonList(ctx, app.ListRequest{})
+	require.NoError(t, err)
+	require.Empty(t, page.Persons)
+
+	history, err := logic.GetPersonHistory(ctx, a.Id)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, app.ActionDelete, history[2].Action)
+
+	_, err = logic.DeletePersons(ctx, nil, app.BulkAtomic)
+	require.ErrorIs(t, err, app.ErrValidation)
+
+	_, err = logic.DeletePersons(ctx, []app.PersonRef{{ID: a.Id}}, "sometimes")
+	require.ErrorIs(t, err, app.ErrValidation)
+}
@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+)
+
+// ImportPerson stores an imported person, unless its email is already in use.
+// Then the person is skipped, updates the one using the email or fails, depending on the policy.
+func (p *PerLogic) ImportPerson(ctx context.Context, per *app.Person, opts app.ImportOptions) (app.ImportOutcome, error) {
+	if !opts.OnDuplicate.Valid() {
+		return "", fmt.Errorf("%w: duplicate policy must be %s, %s or %s, got %q",
+			app.ErrValidation, app.DuplicateSkip, app.DuplicateUpdate, app.DuplicateFail, opts.OnDuplicate)
+	}
+
+	if err := p.normalizePerson(per); err != nil {
+		return "", err
+	}
+
+	existing, err := p.personByEmail(ctx, per.Email)
+	if err != nil {
+		return "", fmt.Errorf("can't check if the email is already using: %w", err)
+	}
+
+	// A real run would have stored the person of an earlier row by now.
+	if existing == nil && opts.DryRun && opts.Batch != nil {
+		if created, ok := opts.Batch.Created(per.Email); ok {
+			existing = &created
+		}
+	}
+
+	if existing == nil {
+		if !opts.DryRun {
+			if err := p.StorePerson(ctx, per); err != nil {
+				return "", err
+			}
+		} else if opts.Batch != nil {
+			opts.Batch.AddCreated(*per)
+		}
+
+		return app.ImportCreated, nil
+	}
+
+	switch opts.OnDuplicate {
+	case app.DuplicateSkip:
+		*per = *existing
+
+		return app.ImportSkipped, nil
+	case app.DuplicateUpdate:
+		per.Id, per.Version = existing.Id, existing.Version
+
+		if !opts.DryRun {
+			if err := p.UpdatePerson(ctx, per); err != nil {
+				return "", err
+			}
+		}
+
+		return app.ImportUpdated, nil
+	}
+
+	return "", fmt.Errorf("%w: another person with email address %s already exists", app.ErrEmailConflict, per.Email)
+}
+
+// personByEmail returns the live person using the email or nil if there is none.
+func (p *PerLogic) personByEmail(ctx context.Context, email string) (*app.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+
+	per, err := p.perRepo.GetByEmail(ctx, email, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if per.Id == 0 {
+		return nil, nil
+	}
+
+	return per, nil
+}
@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/memory"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPerLogic_ImportPerson(t *testing.T) {
+	repo := memory.NewPersonRepo()
+	logic := NewPersonLogic(repo, time.Second, "")
+	ctx := context.Background()
+
+	imported := func(lastName string) *app.Person {
+		return &app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: lastName}
+	}
+
+	outcome, err := logic.ImportPerson(ctx, imported("Smith"), app.ImportOptions{OnDuplicate: app.DuplicateFail, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, app.ImportCreated, outcome)
+
+	_, err = logic.ImportPerson(ctx, imported("Smith"), app.ImportOptions{OnDuplicate: "merge"})
+	require.ErrorIs(t, err, app.ErrValidation)
+
+	page, err := logic.GetPersonList(ctx, app.ListRequest{})
+	require.NoError(t, err)
+	require.Empty(t, page.Persons, "dry run must not store anything")
+
+	per := imported("Smith")
+	outcome, err = logic.ImportPerson(ctx, per, app.ImportOptions{OnDuplicate: app.DuplicateFail})
+	require.NoError(t, err)
+	require.Equal(t, app.ImportCreated, outcome)
+	require.NotZero(t, per.Id)
+
+	_, err = logic.ImportPerson(ctx, imported("Doe"), app.ImportOptions{OnDuplicate: app.DuplicateFail})
+	require.ErrorIs(t, err, app.ErrEmailConflict)
+
+	skipped := imported("Doe")
+	outcome, err = logic.ImportPerson(ctx, skipped, app.ImportOptions{OnDuplicate: app.DuplicateSkip})
+	require.NoError(t, err)
+	require.Equal(t, app.ImportSkipped, outcome)
+	require.Equal(t, "Smith", skipped.LastName)
+
+	outcome, err = logic.ImportPerson(ctx, imported("Doe"), app.ImportOptions{OnDuplicate: app.DuplicateUpdate, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, app.ImportUpdated, outcome)
+
+	stored, err := logic.GetPersonByID(ctx, per.Id, false)
+	require.NoError(t, err)
+	require.Equal(t, "Smith", stored.LastName)
+
+	updated := imported("Doe")
+	outcome, err = logic.ImportPerson(ctx, updated, app.ImportOptions{OnDuplicate: app.DuplicateUpdate})
+	require.NoError(t, err)
+	require.Equal(t, app.ImportUpdated, outcome)
+	require.Equal(t, per.Id, updated.Id)
+	require.Equal(t, per.Version+1, updated.Version)
+}
+
+func TestPerLogic_ImportPerson_DryRunBatch(t *testing.T) {
+	logic := NewPersonLogic(memory.NewPersonRepo(), time.Second, "")
+	ctx := context.Background()
+
+	imported := func(lastName string) *app.Person {
+		return &app.Person{Email: "John@Example.com", Phone: "+14155550101", FirstName: "John", LastName: lastName}
+	}
+
+	testTable := []struct {
+		name        string
+		policy      app.DuplicatePolicy
+		expected    app.ImportOutcome
+		expectedErr error
+	}{
+		{name: "Fail", policy: app.DuplicateFail, expectedErr: app.ErrEmailConflict},
+		{name: "Skip", policy: app.DuplicateSkip, expected: app.ImportSkipped},
+		{name: "Update", policy: app.DuplicateUpdate, expected: app.ImportUpdated},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			opts := app.ImportOptions{OnDuplicate: testCase.policy, DryRun: true, Batch: app.NewImportBatch()}
+
+			outcome, err := logic.ImportPerson(ctx, imported("Smith"), opts)
+			require.NoError(t, err)
+			require.Equal(t, app.ImportCreated, outcome)
+
+			// The same email in another row is a duplicate, as it would be in a real run.
+			outcome, err = logic.ImportPerson(ctx, imported("Doe"), opts)
+			require.ErrorIs(t, err, testCase.expectedErr)
+			require.Equal(t, testCase.expected, outcome)
+		})
+	}
+}
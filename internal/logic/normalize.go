@@ -0,0 +1,84 @@
+package logic
+
+import (
+	"errors"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+)
+
+// normalizePerson brings the person fields to their canonical form, so that equal values are stored alike.
+// All the invalid fields are reported at once by *app.ValidationError, the person is left as it is then.
+func (p *PerLogic) normalizePerson(per *app.Person) error {
+	var verr app.ValidationError
+
+	normalized := *per
+
+	normalize(&verr, "email", &normalized.Email, app.NormalizeEmail)
+	normalize(&verr, "phone", &normalized.Phone, p.normalizePhone)
+	normalize(&verr, "firstName", &normalized.FirstName, app.NormalizeName)
+	normalize(&verr, "lastName", &normalized.LastName, app.NormalizeName)
+
+	if err := verr.Err(); err != nil {
+		return err
+	}
+
+	*per = normalized
+
+	return nil
+}
+
+// normalizePatch normalizes the fields the patch changes, it doesn't modify the values they point to.
+func (p *PerLogic) normalizePatch(patch app.PersonPatch) (app.PersonPatch, error) {
+	var verr app.ValidationError
+
+	for _, f := range []struct {
+		name      string
+		value     **string
+		normalize func(string) (string, error)
+	}{
+		{"email", &patch.Email, app.NormalizeEmail},
+		{"phone", &patch.Phone, p.normalizePhone},
+		{"firstName", &patch.FirstName, app.NormalizeName},
+		{"lastName", &patch.LastName, app.NormalizeName},
+	} {
+		if *f.value == nil {
+			continue
+		}
+
+		value := **f.value
+		normalize(&verr, f.name, &value, f.normalize)
+		*f.value = &value
+	}
+
+	return patch, verr.Err()
+}
+
+// normalizePersons normalizes copies of the persons, the first invalid one is reported by *app.BatchItemError.
+func (p *PerLogic) normalizePersons(persons []app.Person) ([]app.Person, error) {
+	normalized := make([]app.Person, len(persons))
+	copy(normalized, persons)
+
+	for i := range normalized {
+		if err := p.normalizePerson(&normalized[i]); err != nil {
+			return nil, &app.BatchItemError{Index: i, Err: err}
+		}
+	}
+
+	return normalized, nil
+}
+
+func normalize(verr *app.ValidationError, field string, value *string, fn func(string) (string, error)) {
+	normalized, err := fn(*value)
+
+	var rerr *app.RuleError
+	if errors.As(err, &rerr) {
+		verr.Add(field, rerr.Rule, field+" "+rerr.Message)
+
+		return
+	}
+
+	*value = normalized
+}
+
+func (p *PerLogic) normalizePhone(phone string) (string, error) {
+	return app.NormalizePhone(phone, p.phoneRegion)
+}
@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPerLogic_NormalizePerson(t *testing.T) {
+	testTable := []struct {
+		name        string
+		region      string
+		input       app.Person
+		expected    app.Person
+		expectedErr *app.ValidationError
+	}{
+		{
+			name:     "Canonical",
+			input:    app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"},
+			expected: app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"},
+		}, {
+			name:     "Messy",
+			input:    app.Person{Email: "  John.Smith@Example.COM ", Phone: "+1 (415) 555-0101", FirstName: " Mary \t Ann", LastName: "Smith "},
+			expected: app.Person{Email: "john.smith@example.com", Phone: "+14155550101", FirstName: "Mary Ann", LastName: "Smith"},
+		}, {
+			name:     "Default Region",
+			region:   "GB",
+			input:    app.Person{Email: "john@example.com", Phone: "020 7183 8750", FirstName: "John", LastName: "Smith"},
+			expected: app.Person{Email: "john@example.com", Phone: "+442071838750", FirstName: "John", LastName: "Smith"},
+		}, {
+			name:   "Invalid",
+			region: "US",
+			input:  app.Person{Email: "John <john@example.com>", Phone: "12345", FirstName: "John\x00", LastName: strings.Repeat("a", app.MaxNameLength+1)},
+			expectedErr: &app.ValidationError{Fields: []app.FieldError{
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+				{Field: "phone", Rule: "phone", Message: "phone must be a valid phone number"},
+				{Field: "firstName", Rule: "person_name", Message: "firstName must not contain control characters"},
+				{Field: "lastName", Rule: "max", Message: "lastName must be at most 100 characters long"},
+			}},
+		}, {
+			name:  "Missing",
+			input: app.Person{Email: "x", Phone: "415 555 0101"},
+			expectedErr: &app.ValidationError{Fields: []app.FieldError{
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+				{Field: "phone", Rule: "phone", Message: "phone must be a valid phone number with a country code"},
+				{Field: "firstName", Rule: "required", Message: "firstName is required"},
+				{Field: "lastName", Rule: "required", Message: "lastName is required"},
+			}},
+		}, {
+			name:  "Email Without Domain Name",
+			input: app.Person{Email: "john@localhost", Phone: "+14155550101", FirstName: "John", LastName: "Smith"},
+			expectedErr: &app.ValidationError{Fields: []app.FieldError{
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+			}},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			logic := NewPersonLogic(nil, time.Second, testCase.region)
+
+			per := testCase.input
+			err := logic.normalizePerson(&per)
+
+			if testCase.expectedErr != nil {
+				require.Equal(t, testCase.expectedErr, err)
+				require.ErrorIs(t, err, app.ErrValidation)
+				require.Equal(t, testCase.input, per, "invalid person must be left as it is")
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, per)
+		})
+	}
+}
+
+func TestPerLogic_NormalizePatch(t *testing.T) {
+	logic := NewPersonLogic(nil, time.Second, "")
+
+	email := "John@Example.com"
+	patch, err := logic.normalizePatch(app.PersonPatch{Email: &email})
+	require.NoError(t, err)
+	require.Equal(t, "john@example.com", *patch.Email)
+	require.Equal(t, "John@Example.com", email, "values of the patch must not be modified")
+	require.Nil(t, patch.Phone)
+
+	phone := "555"
+	_, err = logic.normalizePatch(app.PersonPatch{Email: &email, Phone: &phone})
+	require.Equal(t, &app.ValidationError{Fields: []app.FieldError{
+		{Field: "phone", Rule: "phone", Message: "phone must be a valid phone number with a country code"},
+	}}, err)
+}
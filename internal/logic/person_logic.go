@@ -4,46 +4,107 @@ import (
 	"context"
 	"fmt"
 	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type PerLogic struct {
-	perRepo    app.PersonRepository
-	ctxTimeout time.Duration
+	perRepo app.PersonRepository
+	// ctxTimeout is the time.Duration every call is limited by, it is changed when the configuration is reloaded.
+	ctxTimeout atomic.Int64
+	// phoneRegion is the region of phone numbers given without a country code, none are accepted if it is empty.
+	phoneRegion string
 }
 
-func NewPersonLogic(perRep app.PersonRepository, timeout time.Duration) *PerLogic {
-	return &PerLogic{perRepo: perRep, ctxTimeout: timeout}
+func NewPersonLogic(perRep app.PersonRepository, timeout time.Duration, phoneRegion string) *PerLogic {
+	p := &PerLogic{perRepo: perRep, phoneRegion: phoneRegion}
+	p.SetTimeout(timeout)
+
+	return p
+}
+
+// SetTimeout changes the timeout of the calls started afterwards.
+func (p *PerLogic) SetTimeout(timeout time.Duration) {
+	p.ctxTimeout.Store(int64(timeout))
+}
+
+func (p *PerLogic) timeout() time.Duration {
+	return time.Duration(p.ctxTimeout.Load())
 }
 
 func (p *PerLogic) StorePerson(ctx context.Context, per *app.Person) error {
-	ctx, cancel := context.WithTimeout(ctx, p.ctxTimeout)
+	normalized := *per
+
+	if err := p.normalizePerson(&normalized); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
 	defer cancel()
 
-	ok, err := p.isEmailExist(ctx, per.Email, 0)
+	var stored app.Person
+
+	err := p.perRepo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		stored = normalized
+
+		// Email uniqueness is enforced by the database, a check beforehand would race with concurrent requests.
+		if err := repo.Store(ctx, &stored); err != nil {
+			return err
+		}
+
+		return audit(ctx, repo, app.ActionCreate, nil, &stored)
+	})
+
 	if err != nil {
-		return fmt.Errorf("can't check is person already exist: %w", err)
+		return err
 	}
 
-	if ok {
-		return fmt.Errorf("another person with email address: %s already exist", per.Email)
-	}
+	*per = stored
+
+	logging.FromContext(ctx).WithField("person_id", stored.Id).Debug("person stored")
 
-	return p.perRepo.Store(ctx, per)
+	return nil
 }
 
-func (p *PerLogic) DeletePerson(ctx context.Context, id int) error {
-	ctx, cancel := context.WithTimeout(ctx, p.ctxTimeout)
+func (p *PerLogic) DeletePerson(ctx context.Context, id int, version int) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
 	defer cancel()
 
-	return p.perRepo.Delete(ctx, id)
+	err := p.perRepo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		before, err := repo.GetByID(ctx, id, false)
+		if err != nil {
+			return fmt.Errorf("can't delete person: %w", err)
+		}
+
+		if err := repo.Delete(ctx, id, version); err != nil {
+			return err
+		}
+
+		after, err := repo.GetByID(ctx, id, true)
+		if err != nil {
+			return fmt.Errorf("can't get deleted person: %w", err)
+		}
+
+		return audit(ctx, repo, app.ActionDelete, before, after)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithField("person_id", id).Debug("person deleted")
+
+	return nil
 }
 
-func (p *PerLogic) GetPersonByID(ctx context.Context, id int) (*app.Person, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.ctxTimeout)
+func (p *PerLogic) GetPersonByID(ctx context.Context, id int, includeDeleted bool) (*app.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
 	defer cancel()
 
-	person, err := p.perRepo.GetByID(ctx, id)
+	person, err := p.perRepo.GetByID(ctx, id, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -52,39 +113,342 @@ func (p *PerLogic) GetPersonByID(ctx context.Context, id int) (*app.Person, erro
 }
 
 func (p *PerLogic) UpdatePerson(ctx context.Context, per *app.Person) error {
-	ctx, cancel := context.WithTimeout(ctx, p.ctxTimeout)
+	normalized := *per
+
+	if err := p.normalizePerson(&normalized); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
 	defer cancel()
 
-	if err := p.isPersonExist(ctx, per.Id); err != nil {
-		return fmt.Errorf("can't update person: %w", err)
+	var updated app.Person
+
+	err := p.perRepo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		updated = normalized
+
+		before, err := repo.GetByID(ctx, updated.Id, false)
+		if err != nil {
+			return fmt.Errorf("can't update person: %w", err)
+		}
+
+		ok, err := isEmailExist(ctx, repo, updated.Email, updated.Id)
+		if err != nil {
+			return fmt.Errorf("can't check if the email is already using: %w", err)
+		}
+
+		if ok {
+			return fmt.Errorf("%w: another person already using this email address: %s", app.ErrEmailConflict, updated.Email)
+		}
+
+		if err := repo.Update(ctx, &updated); err != nil {
+			return err
+		}
+
+		return audit(ctx, repo, app.ActionUpdate, before, &updated)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	*per = updated
+
+	logging.FromContext(ctx).WithField("person_id", updated.Id).Debug("person updated")
+
+	return nil
+}
+
+// Upsert stores the person or updates the one using its email, whichever has happened is audited.
+// A version of the person makes it update only the person of that version.
+func (p *PerLogic) Upsert(ctx context.Context, per *app.Person) (bool, error) {
+	normalized := *per
+
+	if err := p.normalizePerson(&normalized); err != nil {
+		return false, err
 	}
 
-	ok, err := p.isEmailExist(ctx, per.Email, per.Id)
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+
+	var (
+		upserted app.Person
+		created  bool
+	)
+
+	err := p.perRepo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		upserted = normalized
+
+		// The person is locked for the audit to diff the very row the upsert updates.
+		before, err := repo.GetByEmailForUpdate(ctx, upserted.Email)
+		if err != nil {
+			return fmt.Errorf("can't upsert person: %w", err)
+		}
+
+		if normalized.Version != 0 {
+			if before.Id == 0 {
+				return fmt.Errorf("%w: person with email address %s doesn't exist", app.ErrVersionMismatch, upserted.Email)
+			}
+
+			if before.Version != normalized.Version {
+				return fmt.Errorf("%w: expected version %d, current is %d", app.ErrVersionMismatch, normalized.Version, before.Version)
+			}
+		}
+
+		// Upserting the person as it is changes nothing, neither its version nor its history.
+		if before.Id != 0 && before.Phone == upserted.Phone &&
+			before.FirstName == upserted.FirstName && before.LastName == upserted.LastName {
+			upserted = *before
+
+			return nil
+		}
+
+		created, err = repo.Upsert(ctx, &upserted)
+		if err != nil {
+			return err
+		}
+
+		if created {
+			return audit(ctx, repo, app.ActionCreate, nil, &upserted)
+		}
+
+		// A person stored meanwhile by another transaction has been updated, its previous state is unknown.
+		if before.Id == 0 {
+			return fmt.Errorf("%w: person with email address %s has been stored concurrently", app.ErrConcurrentUpdate, upserted.Email)
+		}
+
+		return audit(ctx, repo, app.ActionUpdate, before, &upserted)
+	})
+
 	if err != nil {
-		return fmt.Errorf("can't check if the email is already using: %w", err)
+		return false, err
 	}
 
-	if ok {
-		return fmt.Errorf("another person already using this email address: %s", per.Email)
+	*per = upserted
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{"person_id": upserted.Id, "created": created}).Debug("person upserted")
+
+	return created, nil
+}
+
+func (p *PerLogic) PatchPerson(ctx context.Context, id int, version int, patch app.PersonPatch) (*app.Person, error) {
+	patch, err := p.normalizePatch(patch)
+	if err != nil {
+		return nil, err
 	}
 
-	return p.perRepo.Update(ctx, per)
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+
+	var patched *app.Person
+
+	err = p.perRepo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		current, err := repo.GetByID(ctx, id, false)
+		if err != nil {
+			return fmt.Errorf("can't patch person: %w", err)
+		}
+
+		if current.Version != version {
+			return fmt.Errorf("%w: expected version %d, current is %d", app.ErrVersionMismatch, version, current.Version)
+		}
+
+		if patch.IsEmpty() {
+			patched = current
+
+			return nil
+		}
+
+		if patch.Email != nil && *patch.Email != current.Email {
+			ok, err := isEmailExist(ctx, repo, *patch.Email, id)
+			if err != nil {
+				return fmt.Errorf("can't check if the email is already using: %w", err)
+			}
+
+			if ok {
+				return fmt.Errorf("%w: another person already using this email address: %s", app.ErrEmailConflict, *patch.Email)
+			}
+		}
+
+		patched, err = repo.Patch(ctx, id, version, patch)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, repo, app.ActionPatch, current, patched)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).WithField("person_id", id).Debug("person patched")
+
+	return patched, nil
 }
 
-func (p *PerLogic) GetPersonList(ctx context.Context, offsetId int, batchSize int) ([]app.Person, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.ctxTimeout)
+func (p *PerLogic) GetPersonList(ctx context.Context, req app.ListRequest) (*app.PersonPage, error) {
+	limit, err := pageSize(req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emails are stored lowercased.
+	req.Filter.Email = strings.ToLower(strings.TrimSpace(req.Filter.Email))
+
+	sort := req.Sort
+	if sort.Field == "" {
+		sort.Field = app.SortByID
+	}
+
+	if !sort.Field.Valid() {
+		return nil, fmt.Errorf("%w: unknown sort field %q", app.ErrValidation, sort.Field)
+	}
+
+	// One extra row tells whether there is a next page.
+	query := app.ListQuery{Filter: req.Filter, Sort: sort, Limit: limit + 1}
+
+	if req.Cursor != "" {
+		cursor, err := app.DecodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		if cursor.Sort != sort.Key() {
+			return nil, fmt.Errorf("%w: cursor was issued for another sort order", app.ErrValidation)
+		}
+
+		query.After = &cursor
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
 	defer cancel()
 
-	personList, err := p.perRepo.GetPersonList(ctx, offsetId, batchSize)
+	personList, err := p.perRepo.GetPersonList(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("getting persons list failed: %w", err)
 	}
 
-	return personList, nil
+	page := &app.PersonPage{Persons: personList}
+
+	if len(personList) > limit {
+		last := &personList[limit-1]
+
+		page.Persons = personList[:limit]
+		page.NextCursor = app.Cursor{ID: last.Id, Sort: sort.Key(), Value: sort.Field.ValueOf(last)}.Encode()
+	}
+
+	return page, nil
 }
 
-func (p *PerLogic) isEmailExist(ctx context.Context, email string, id int) (bool, error) {
-	per, err := p.perRepo.GetByEmail(ctx, email, id)
+func (p *PerLogic) SearchPersons(ctx context.Context, query string, limit int) ([]app.PersonMatch, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: search query must not be empty", app.ErrValidation)
+	}
+
+	limit, err := pageSize(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+
+	matches, err := p.perRepo.Search(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("searching persons failed: %w", err)
+	}
+
+	return matches, nil
+}
+
+func (p *PerLogic) RestorePerson(ctx context.Context, id int, version int) (*app.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+
+	var restored *app.Person
+
+	err := p.perRepo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		before, err := repo.GetByID(ctx, id, true)
+		if err != nil {
+			return fmt.Errorf("can't restore person: %w", err)
+		}
+
+		restored, err = repo.Restore(ctx, id, version)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, repo, app.ActionRestore, before, restored)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).WithField("person_id", id).Debug("person restored")
+
+	return restored, nil
+}
+
+func (p *PerLogic) GetPersonHistory(ctx context.Context, id int) ([]app.AuditRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+
+	history, err := p.perRepo.GetHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(history) == 0 {
+		return nil, fmt.Errorf("%w: person with ID %d has no history", app.ErrNotFound, id)
+	}
+
+	return history, nil
+}
+
+// GetPersonAsOf reconstructs the state of the person at the given time from its history.
+func (p *PerLogic) GetPersonAsOf(ctx context.Context, id int, t time.Time) (*app.Person, error) {
+	history, err := p.GetPersonHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	person, err := app.PersonAsOf(history, t)
+	if err != nil {
+		return nil, fmt.Errorf("can't get person with ID %d: %w", id, err)
+	}
+
+	return person, nil
+}
+
+// audit records the change of a person made in the transaction of repo.
+func audit(ctx context.Context, repo app.PersonRepository, action app.AuditAction, before, after *app.Person) error {
+	rec, err := auditRecord(ctx, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return repo.AddAudit(ctx, rec)
+}
+
+func auditRecord(ctx context.Context, action app.AuditAction, before, after *app.Person) (*app.AuditRecord, error) {
+	changes, err := app.Diff(before, after)
+	if err != nil {
+		return nil, fmt.Errorf("can't compute changes of person: %w", err)
+	}
+
+	return &app.AuditRecord{
+		PersonID:  after.Id,
+		Action:    action,
+		Actor:     app.ActorFromContext(ctx),
+		RequestID: app.RequestIDFromContext(ctx),
+		Changes:   changes,
+	}, nil
+}
+
+func isEmailExist(ctx context.Context, repo app.PersonRepository, email string, id int) (bool, error) {
+	per, err := repo.GetByEmail(ctx, email, id)
 	if err != nil {
 		return false, err
 	}
@@ -96,11 +460,15 @@ func (p *PerLogic) isEmailExist(ctx context.Context, email string, id int) (bool
 	return false, nil
 }
 
-func (p *PerLogic) isPersonExist(ctx context.Context, id int) error {
-	_, err := p.perRepo.GetByID(ctx, id)
-	if err != nil {
-		return err
+func pageSize(limit int) (int, error) {
+	switch {
+	case limit < 0:
+		return 0, fmt.Errorf("%w: limit must be positive", app.ErrValidation)
+	case limit == 0:
+		return app.DefaultPageSize, nil
+	case limit > app.MaxPageSize:
+		return app.MaxPageSize, nil
 	}
 
-	return nil
+	return limit, nil
 }
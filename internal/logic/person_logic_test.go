@@ -0,0 +1,602 @@
+package logic
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app/mock"
+	"github.com/EgorMamoshkin/person-api-crud/internal/memory"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// expectTx lets the logic run transactions on the mocked repository itself.
+func expectTx(repo *mock_app.MockPersonRepository) {
+	repo.EXPECT().WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fn func(app.PersonRepository) error) error {
+			return fn(repo)
+		}).AnyTimes()
+}
+
+func TestPerLogic_GetPersonList(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonRepository)
+
+	persons := []app.Person{{Id: 3, LastName: "Adams"}, {Id: 7, LastName: "Brown"}, {Id: 12, LastName: "Clark"}}
+	byID := app.Sort{Field: app.SortByID}
+	byName := app.Sort{Field: app.SortByLastName, Desc: true}
+
+	testTable := []struct {
+		name string
+		req  app.ListRequest
+		mockBehavior
+		expected    *app.PersonPage
+		expectedErr error
+	}{
+		{
+			name: "Has Next Page",
+			req:  app.ListRequest{Limit: 2},
+			mockBehavior: func(s *mock_app.MockPersonRepository) {
+				s.EXPECT().GetPersonList(gomock.Any(), app.ListQuery{Sort: byID, Limit: 3}).Return(persons, nil)
+			},
+			expected: &app.PersonPage{Persons: persons[:2], NextCursor: app.Cursor{ID: 7}.Encode()},
+		}, {
+			name: "Last Page",
+			req:  app.ListRequest{Cursor: app.Cursor{ID: 7}.Encode(), Limit: 2},
+			mockBehavior: func(s *mock_app.MockPersonRepository) {
+				s.EXPECT().GetPersonList(gomock.Any(), app.ListQuery{Sort: byID, After: &app.Cursor{ID: 7}, Limit: 3}).Return(persons[2:], nil)
+			},
+			expected: &app.PersonPage{Persons: persons[2:]},
+		}, {
+			name: "Default Limit",
+			mockBehavior: func(s *mock_app.MockPersonRepository) {
+				s.EXPECT().GetPersonList(gomock.Any(), app.ListQuery{Sort: byID, Limit: app.DefaultPageSize + 1}).Return(persons, nil)
+			},
+			expected: &app.PersonPage{Persons: persons},
+		}, {
+			name: "Limit Capped",
+			req:  app.ListRequest{Limit: 1000},
+			mockBehavior: func(s *mock_app.MockPersonRepository) {
+				s.EXPECT().GetPersonList(gomock.Any(), app.ListQuery{Sort: byID, Limit: app.MaxPageSize + 1}).Return(persons, nil)
+			},
+			expected: &app.PersonPage{Persons: persons},
+		}, {
+			name: "Sorted By Last Name",
+			req:  app.ListRequest{Sort: byName, Limit: 2},
+			mockBehavior: func(s *mock_app.MockPersonRepository) {
+				s.EXPECT().GetPersonList(gomock.Any(), app.ListQuery{Sort: byName, Limit: 3}).Return(persons, nil)
+			},
+			expected: &app.PersonPage{Persons: persons[:2], NextCursor: app.Cursor{ID: 7, Sort: "last_name:desc", Value: "Brown"}.Encode()},
+		}, {
+			name:         "Cursor Of Another Sort",
+			req:          app.ListRequest{Sort: byName, Cursor: app.Cursor{ID: 7}.Encode()},
+			mockBehavior: func(s *mock_app.MockPersonRepository) {},
+			expectedErr:  app.ErrValidation,
+		}, {
+			name:         "Unknown Sort Field",
+			req:          app.ListRequest{Sort: app.Sort{Field: "phone"}},
+			mockBehavior: func(s *mock_app.MockPersonRepository) {},
+			expectedErr:  app.ErrValidation,
+		}, {
+			name:         "Negative Limit",
+			req:          app.ListRequest{Limit: -1},
+			mockBehavior: func(s *mock_app.MockPersonRepository) {},
+			expectedErr:  app.ErrValidation,
+		}, {
+			name:         "Malformed Cursor",
+			req:          app.ListRequest{Cursor: "%%%"},
+			mockBehavior: func(s *mock_app.MockPersonRepository) {},
+			expectedErr:  app.ErrValidation,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock_app.NewMockPersonRepository(ctrl)
+			testCase.mockBehavior(repo)
+
+			logic := NewPersonLogic(repo, time.Second, "")
+
+			page, err := logic.GetPersonList(context.Background(), testCase.req)
+
+			if testCase.expectedErr != nil {
+				require.ErrorIs(t, err, testCase.expectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, page)
+		})
+	}
+}
+
+func TestPerLogic_StorePerson(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonRepository, per *app.Person)
+
+	testTable := []struct {
+		name  string
+		input *app.Person
+		mockBehavior
+		expectedErr error
+	}{
+		{
+			name:  "OK",
+			input: &app.Person{Email: " Test@Gmail.com", Phone: "+1 (415) 555-0101", FirstName: "Mary  Ann ", LastName: "Test"},
+			mockBehavior: func(s *mock_app.MockPersonRepository, per *app.Person) {
+				normalized := &app.Person{Email: "test@gmail.com", Phone: "+14155550101", FirstName: "Mary Ann", LastName: "Test"}
+
+				s.EXPECT().Store(gomock.Any(), normalized).Return(nil)
+				s.EXPECT().AddAudit(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		}, {
+			name:  "Email Conflict",
+			input: &app.Person{Email: "test@gmail.com", Phone: "+14155550101", FirstName: "Test", LastName: "Test"},
+			mockBehavior: func(s *mock_app.MockPersonRepository, per *app.Person) {
+				s.EXPECT().Store(gomock.Any(), per).Return(app.ErrEmailConflict)
+			},
+			expectedErr: app.ErrEmailConflict,
+		}, {
+			name:         "Invalid",
+			input:        &app.Person{Email: "x", Phone: "415 555 0101", FirstName: "Test", LastName: " "},
+			mockBehavior: func(s *mock_app.MockPersonRepository, per *app.Person) {},
+			expectedErr:  app.ErrValidation,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock_app.NewMockPersonRepository(ctrl)
+			expectTx(repo)
+			testCase.mockBehavior(repo, testCase.input)
+
+			logic := NewPersonLogic(repo, time.Second, "")
+
+			err := logic.StorePerson(context.Background(), testCase.input)
+
+			if testCase.expectedErr != nil {
+				require.ErrorIs(t, err, testCase.expectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPerLogic_SearchPersons(t *testing.T) {
+	repo := memory.NewPersonRepo()
+	logic := NewPersonLogic(repo, time.Second, "")
+	ctx := context.Background()
+
+	for _, per := range []*app.Person{
+		{Email: "john.smith@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"},
+		{Email: "jane@example.com", Phone: "+14155550102", FirstName: "Jane", LastName: "Doe"},
+		{Email: "johnny@example.com", Phone: "+14155550103", FirstName: "Johnny", LastName: "Smithers"},
+	} {
+		require.NoError(t, logic.StorePerson(ctx, per))
+	}
+
+	testTable := []struct {
+		name        string
+		query       string
+		limit       int
+		expectedIDs []int
+		expectedErr error
+	}{
+		{
+			name:        "Typo",
+			query:       "Jonh Smith",
+			expectedIDs: []int{1, 3},
+		}, {
+			name:        "Ranked",
+			query:       "smith",
+			expectedIDs: []int{1, 3},
+		}, {
+			name:        "Limited",
+			query:       "smith",
+			limit:       1,
+			expectedIDs: []int{1},
+		}, {
+			name:        "No Match",
+			query:       "Zebediah",
+			expectedIDs: []int{},
+		}, {
+			name:        "Empty Query",
+			query:       "  ",
+			expectedErr: app.ErrValidation,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			matches, err := logic.SearchPersons(ctx, testCase.query, testCase.limit)
+
+			if testCase.expectedErr != nil {
+				require.ErrorIs(t, err, testCase.expectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			ids := make([]int, 0, len(matches))
+			for _, m := range matches {
+				ids = append(ids, m.Id)
+			}
+
+			require.Equal(t, testCase.expectedIDs, ids)
+		})
+	}
+}
+
+func TestPerLogic_UpdatePerson(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonRepository, per *app.Person)
+
+	current := &app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", Version: 1}
+
+	testTable := []struct {
+		name  string
+		input app.Person
+		mockBehavior
+		expectedVersion int
+		expectedErr     error
+	}{
+		{
+			name:  "OK",
+			input: app.Person{Id: 1, Email: "new@gmail.com", Phone: "+14155550101", FirstName: "Test", LastName: "Test", Version: 1},
+			mockBehavior: func(s *mock_app.MockPersonRepository, per *app.Person) {
+				s.EXPECT().GetByID(gomock.Any(), 1, false).Return(current, nil)
+				s.EXPECT().GetByEmail(gomock.Any(), "new@gmail.com", 1).Return(&app.Person{}, nil)
+				s.EXPECT().Update(gomock.Any(), per).DoAndReturn(func(_ context.Context, per *app.Person) error {
+					per.Version++
+
+					return nil
+				})
+				s.EXPECT().AddAudit(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedVersion: 2,
+		}, {
+			name:  "Email Taken",
+			input: app.Person{Id: 1, Email: "new@gmail.com", Phone: "+14155550101", FirstName: "Test", LastName: "Test", Version: 1},
+			mockBehavior: func(s *mock_app.MockPersonRepository, per *app.Person) {
+				s.EXPECT().GetByID(gomock.Any(), 1, false).Return(current, nil)
+				s.EXPECT().GetByEmail(gomock.Any(), "new@gmail.com", 1).Return(&app.Person{Id: 2, Email: "new@gmail.com"}, nil)
+			},
+			expectedVersion: 1,
+			expectedErr:     app.ErrEmailConflict,
+		}, {
+			name:  "Not Found",
+			input: app.Person{Id: 1, Email: "new@gmail.com", Phone: "+14155550101", FirstName: "Test", LastName: "Test", Version: 1},
+			mockBehavior: func(s *mock_app.MockPersonRepository, per *app.Person) {
+				s.EXPECT().GetByID(gomock.Any(), 1, false).Return(nil, app.ErrNotFound)
+			},
+			expectedVersion: 1,
+			expectedErr:     app.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock_app.NewMockPersonRepository(ctrl)
+			expectTx(repo)
+			testCase.mockBehavior(repo, &testCase.input)
+
+			logic := NewPersonLogic(repo, time.Second, "")
+
+			err := logic.UpdatePerson(context.Background(), &testCase.input)
+
+			// A failed transaction must leave the input as it was, it may be run again.
+			require.Equal(t, testCase.expectedVersion, testCase.input.Version)
+
+			if testCase.expectedErr != nil {
+				require.ErrorIs(t, err, testCase.expectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPerLogic_PatchPerson(t *testing.T) {
+	type mockBehavior func(s *mock_app.MockPersonRepository, patch app.PersonPatch)
+
+	current := &app.Person{Id: 1, Email: "test@gmail.com", Phone: "+1111111", FirstName: "Test", LastName: "Test", Version: 2}
+	sameEmail := "test@gmail.com"
+	newEmail := "new@gmail.com"
+	newPhone := "+14155550102"
+
+	testTable := []struct {
+		name    string
+		version int
+		patch   app.PersonPatch
+		mockBehavior
+		expectedErr error
+	}{
+		{
+			name:  "Email Unchanged",
+			patch: app.PersonPatch{Email: &sameEmail, Phone: &newPhone},
+			mockBehavior: func(s *mock_app.MockPersonRepository, patch app.PersonPatch) {
+				s.EXPECT().GetByID(gomock.Any(), 1, false).Return(current, nil)
+				s.EXPECT().Patch(gomock.Any(), 1, 2, patch).Return(current, nil)
+				s.EXPECT().AddAudit(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		}, {
+			name:  "Email Changed",
+			patch: app.PersonPatch{Email: &newEmail},
+			mockBehavior: func(s *mock_app.MockPersonRepository, patch app.PersonPatch) {
+				s.EXPECT().GetByID(gomock.Any(), 1, false).Return(current, nil)
+				s.EXPECT().GetByEmail(gomock.Any(), newEmail, 1).Return(&app.Person{}, nil)
+				s.EXPECT().Patch(gomock.Any(), 1, 2, patch).Return(current, nil)
+				s.EXPECT().AddAudit(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		}, {
+			name:  "Email Taken",
+			patch: app.PersonPatch{Email: &newEmail},
+			mockBehavior: func(s *mock_app.MockPersonRepository, patch app.PersonPatch) {
+				s.EXPECT().GetByID(gomock.Any(), 1, false).Return(current, nil)
+				s.EXPECT().GetByEmail(gomock.Any(), newEmail, 1).Return(&app.Person{Id: 2, Email: newEmail}, nil)
+			},
+			expectedErr: app.ErrEmailConflict,
+		}, {
+			name:  "Empty Patch",
+			patch: app.PersonPatch{},
+			mockBehavior: func(s *mock_app.MockPersonRepository, patch app.PersonPatch) {
+				s.EXPECT().GetByID(gomock.Any(), 1, false).Return(current, nil)
+			},
+		}, {
+			name:  "Not Found",
+			patch: app.PersonPatch{Phone: &newPhone},
+			mockBehavior: func(s *mock_app.MockPersonRepository, patch app.PersonPatch) {
+				s.EXPECT().GetByID(gomock.Any(), 1, false).Return(nil, app.ErrNotFound)
+			},
+			expectedErr: app.ErrNotFound,
+		}, {
+			name:    "Stale Version",
+			version: 1,
+			patch:   app.PersonPatch{Phone: &newPhone},
+			mockBehavior: func(s *mock_app.MockPersonRepository, patch app.PersonPatch) {
+				s.EXPECT().GetByID(gomock.Any(), 1, false).Return(current, nil)
+			},
+			expectedErr: app.ErrVersionMismatch,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			if testCase.version == 0 {
+				testCase.version = current.Version
+			}
+
+			repo := mock_app.NewMockPersonRepository(ctrl)
+			expectTx(repo)
+			testCase.mockBehavior(repo, testCase.patch)
+
+			logic := NewPersonLogic(repo, time.Second, "")
+
+			_, err := logic.PatchPerson(context.Background(), 1, testCase.version, testCase.patch)
+
+			if testCase.expectedErr != nil {
+				require.ErrorIs(t, err, testCase.expectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPerLogic_SoftDelete(t *testing.T) {
+	repo := memory.NewPersonRepo()
+	logic := NewPersonLogic(repo, time.Second, "")
+	ctx := context.Background()
+
+	newPerson := func() *app.Person {
+		return &app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"}
+	}
+
+	per := newPerson()
+	require.NoError(t, logic.StorePerson(ctx, per))
+
+	_, err := logic.RestorePerson(ctx, per.Id, per.Version)
+	require.ErrorIs(t, err, app.ErrNotDeleted)
+
+	require.NoError(t, logic.DeletePerson(ctx, per.Id, per.Version))
+
+	_, err = logic.GetPersonByID(ctx, per.Id, false)
+	require.ErrorIs(t, err, app.ErrNotFound)
+
+	deleted, err := logic.GetPersonByID(ctx, per.Id, true)
+	require.NoError(t, err)
+	require.NotNil(t, deleted.DeletedAt)
+
+	page, err := logic.GetPersonList(ctx, app.ListRequest{})
+	require.NoError(t, err)
+	require.Empty(t, page.Persons)
+
+	page, err = logic.GetPersonList(ctx, app.ListRequest{Filter: app.PersonFilter{IncludeDeleted: true}})
+	require.NoError(t, err)
+	require.Len(t, page.Persons, 1)
+
+	_, err = logic.RestorePerson(ctx, per.Id, per.Version)
+	require.ErrorIs(t, err, app.ErrVersionMismatch)
+
+	restored, err := logic.RestorePerson(ctx, per.Id, deleted.Version)
+	require.NoError(t, err)
+	require.Nil(t, restored.DeletedAt)
+	require.Equal(t, deleted.Version+1, restored.Version)
+
+	require.NoError(t, logic.DeletePerson(ctx, per.Id, restored.Version))
+
+	// The email of a deleted person can be taken, which blocks the restore.
+	require.NoError(t, logic.StorePerson(ctx, newPerson()))
+
+	_, err = logic.RestorePerson(ctx, per.Id, restored.Version+1)
+	require.ErrorIs(t, err, app.ErrEmailConflict)
+}
+
+func TestPerLogic_History(t *testing.T) {
+	repo := memory.NewPersonRepo()
+	logic := NewPersonLogic(repo, time.Second, "")
+
+	ctx := app.ContextWithActor(context.Background(), "operator")
+	ctx = app.ContextWithRequestID(ctx, "req-1")
+
+	per := &app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"}
+	require.NoError(t, logic.StorePerson(ctx, per))
+
+	created := *per
+
+	per.LastName = "Smithers"
+	require.NoError(t, logic.UpdatePerson(ctx, per))
+
+	phone := "+14155550102"
+	patched, err := logic.PatchPerson(ctx, per.Id, per.Version, app.PersonPatch{Phone: &phone})
+	require.NoError(t, err)
+
+	require.NoError(t, logic.DeletePerson(ctx, per.Id, patched.Version))
+
+	history, err := logic.GetPersonHistory(ctx, per.Id)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+
+	actions := make([]app.AuditAction, 0, len(history))
+	for _, rec := range history {
+		require.Equal(t, "operator", rec.Actor)
+		require.Equal(t, "req-1", rec.RequestID)
+
+		actions = append(actions, rec.Action)
+	}
+
+	require.Equal(t, []app.AuditAction{app.ActionCreate, app.ActionUpdate, app.ActionPatch, app.ActionDelete}, actions)
+	require.Equal(t, app.Changes{
+		"lastName": {Before: []byte(`"Smith"`), After: []byte(`"Smithers"`)},
+		"version":  {Before: []byte(`1`), After: []byte(`2`)},
+	}, history[1].Changes)
+
+	asOf, err := logic.GetPersonAsOf(ctx, per.Id, history[0].CreatedAt)
+	require.NoError(t, err)
+	require.Equal(t, created.Version, asOf.Version)
+	require.Equal(t, created.LastName, asOf.LastName)
+
+	asOf, err = logic.GetPersonAsOf(ctx, per.Id, history[3].CreatedAt)
+	require.NoError(t, err)
+	require.Equal(t, phone, asOf.Phone)
+	require.NotNil(t, asOf.DeletedAt)
+
+	_, err = logic.GetPersonAsOf(ctx, per.Id, history[0].CreatedAt.Add(-time.Second))
+	require.ErrorIs(t, err, app.ErrNotFound)
+
+	_, err = logic.GetPersonHistory(ctx, per.Id+1)
+	require.ErrorIs(t, err, app.ErrNotFound)
+}
+
+func TestPerLogic_Upsert(t *testing.T) {
+	repo := memory.NewPersonRepo()
+	logic := NewPersonLogic(repo, time.Second, "")
+	ctx := context.Background()
+
+	per := &app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"}
+
+	created, err := logic.Upsert(ctx, per)
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, 1, per.Version)
+
+	again := &app.Person{Email: "john@example.com", Phone: "+14155550102", FirstName: "John", LastName: "Smith"}
+
+	created, err = logic.Upsert(ctx, again)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, per.Id, again.Id)
+	require.Equal(t, 2, again.Version)
+
+	history, err := logic.GetPersonHistory(ctx, per.Id)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, app.ActionCreate, history[0].Action)
+	require.Equal(t, app.ActionUpdate, history[1].Action)
+	require.Equal(t, app.FieldChange{Before: []byte(`"+14155550101"`), After: []byte(`"+14155550102"`)}, history[1].Changes["phone"])
+
+	same := &app.Person{Email: "John@Example.com", Phone: "+1 415 555 0102", FirstName: "John", LastName: "Smith"}
+
+	created, err = logic.Upsert(ctx, same)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, *again, *same, "upserting the person as it is must not change it")
+
+	history, err = logic.GetPersonHistory(ctx, per.Id)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	// A version makes the upsert update only the person of that version.
+	_, err = logic.Upsert(ctx, &app.Person{Email: "john@example.com", Phone: "+14155550103", FirstName: "John", LastName: "Smith", Version: 1})
+	require.ErrorIs(t, err, app.ErrVersionMismatch)
+
+	_, err = logic.Upsert(ctx, &app.Person{Email: "jane@example.com", Phone: "+14155550103", FirstName: "Jane", LastName: "Smith", Version: 1})
+	require.ErrorIs(t, err, app.ErrVersionMismatch, "a person to be updated must exist")
+
+	// A deleted person doesn't hold its email, so upserting it creates a new one.
+	require.NoError(t, logic.DeletePerson(ctx, again.Id, again.Version))
+
+	created, err = logic.Upsert(ctx, &app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"})
+	require.NoError(t, err)
+	require.True(t, created)
+}
+
+func TestPerLogic_Upsert_StoredConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_app.NewMockPersonRepository(ctrl)
+	expectTx(repo)
+
+	// The person is stored by another transaction after the lookup, so the upsert updates it.
+	repo.EXPECT().GetByEmailForUpdate(gomock.Any(), "john@example.com").Return(&app.Person{}, nil)
+	repo.EXPECT().Upsert(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, per *app.Person) (bool, error) {
+		per.Id, per.Version = 1, 2
+
+		return false, nil
+	})
+
+	logic := NewPersonLogic(repo, time.Second, "")
+
+	_, err := logic.Upsert(context.Background(), &app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"})
+	require.ErrorIs(t, err, app.ErrConcurrentUpdate, "an update of an unknown state can't be audited")
+}
+
+func TestPerLogic_SetTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_app.NewMockPersonRepository(ctrl)
+	repo.EXPECT().GetByID(gomock.Any(), 1, false).DoAndReturn(func(ctx context.Context, _ int, _ bool) (*app.Person, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		require.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+		return &app.Person{Id: 1}, nil
+	})
+
+	logic := NewPersonLogic(repo, time.Second, "")
+	logic.SetTimeout(time.Minute)
+
+	_, err := logic.GetPersonByID(context.Background(), 1, false)
+	require.NoError(t, err)
+}
@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
+	"sync/atomic"
+	"time"
+)
+
+// Purger periodically hard deletes persons that have been soft deleted for longer than the retention period.
+type Purger struct {
+	perRepo   app.PersonRepository
+	retention time.Duration
+	interval  time.Duration
+	timeout   time.Duration
+	// paused is set while purging is switched off by the configuration.
+	paused atomic.Bool
+}
+
+func NewPurger(perRepo app.PersonRepository, retention, interval, timeout time.Duration) *Purger {
+	return &Purger{perRepo: perRepo, retention: retention, interval: interval, timeout: timeout}
+}
+
+// SetEnabled switches purging on and off, a purge in progress isn't interrupted.
+func (p *Purger) SetEnabled(enabled bool) {
+	p.paused.Store(!enabled)
+}
+
+// Run purges deleted persons every interval until ctx is done, intervals are skipped while purging is disabled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if !p.paused.Load() {
+			// Purges interrupted by stopping aren't worth reporting.
+			if _, err := p.Purge(ctx); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Errorf("purging deleted persons failed: %s", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) Purge(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	purged, err := p.perRepo.Purge(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		logging.FromContext(ctx).Infof("purged %d persons deleted more than %s ago", purged, p.retention)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,48 @@
+package logic
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/memory"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPurger_Purge(t *testing.T) {
+	repo := memory.NewPersonRepo()
+	ctx := context.Background()
+
+	per := &app.Person{Email: "john@example.com", Phone: "+1111111111", FirstName: "John", LastName: "Smith"}
+	require.NoError(t, repo.Store(ctx, per))
+	require.NoError(t, repo.Delete(ctx, per.Id, per.Version))
+
+	purged, err := NewPurger(repo, time.Hour, time.Hour, time.Second).Purge(ctx)
+	require.NoError(t, err)
+	require.Zero(t, purged, "persons within the retention period must be kept")
+
+	purged, err = NewPurger(repo, -time.Second, time.Hour, time.Second).Purge(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, purged)
+
+	_, err = repo.GetByID(ctx, per.Id, true)
+	require.ErrorIs(t, err, app.ErrNotFound)
+}
+
+func TestPurger_Run_Disabled(t *testing.T) {
+	repo := memory.NewPersonRepo()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	per := &app.Person{Email: "john@example.com", Phone: "+1111111111", FirstName: "John", LastName: "Smith"}
+	require.NoError(t, repo.Store(ctx, per))
+	require.NoError(t, repo.Delete(ctx, per.Id, per.Version))
+
+	purger := NewPurger(repo, -time.Second, time.Hour, time.Second)
+	purger.SetEnabled(false)
+
+	cancel()
+	purger.Run(ctx)
+
+	_, err := repo.GetByID(context.Background(), per.Id, true)
+	require.NoError(t, err, "disabled purger must keep deleted persons")
+}
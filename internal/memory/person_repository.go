@@ -0,0 +1,573 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// PersonRepo is an in-memory app.PersonRepository for tests and local runs without a database.
+type PersonRepo struct {
+	mu      sync.RWMutex
+	persons map[int]app.Person
+	lastID  int
+	audit   []app.AuditRecord
+
+	// txMu serializes transactions, there is no isolation from calls made outside of them.
+	txMu sync.Mutex
+}
+
+func NewPersonRepo() *PersonRepo {
+	return &PersonRepo{persons: make(map[int]app.Person)}
+}
+
+// WithinTx runs fn holding the transaction lock and rolls the repository back to its previous state when fn fails.
+func (r *PersonRepo) WithinTx(_ context.Context, fn func(repo app.PersonRepository) error) error {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	r.mu.RLock()
+	persons := make(map[int]app.Person, len(r.persons))
+	for id, person := range r.persons {
+		persons[id] = person
+	}
+	lastID, audited := r.lastID, len(r.audit)
+	r.mu.RUnlock()
+
+	if err := fn(txRepo{r}); err != nil {
+		r.mu.Lock()
+		r.persons, r.lastID, r.audit = persons, lastID, r.audit[:audited]
+		r.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// txRepo is the repository handed to a transaction, nested transactions join the outer one.
+type txRepo struct {
+	*PersonRepo
+}
+
+func (t txRepo) WithinTx(_ context.Context, fn func(repo app.PersonRepository) error) error {
+	return fn(t)
+}
+
+func (r *PersonRepo) AddAudit(_ context.Context, recs ...*app.AuditRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rec := range recs {
+		rec.ID = int64(len(r.audit) + 1)
+		rec.CreatedAt = time.Now().UTC()
+
+		r.audit = append(r.audit, *rec)
+	}
+
+	return nil
+}
+
+func (r *PersonRepo) GetHistory(_ context.Context, personID int) ([]app.AuditRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := make([]app.AuditRecord, 0)
+
+	for _, rec := range r.audit {
+		if rec.PersonID == personID {
+			history = append(history, rec)
+		}
+	}
+
+	return history, nil
+}
+
+func (r *PersonRepo) Store(_ context.Context, person *app.Person) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.emailTaken(person.Email, 0) {
+		return fmt.Errorf("%w: another person with email address %s already exists", app.ErrEmailConflict, person.Email)
+	}
+
+	r.lastID++
+
+	person.Id = r.lastID
+	person.CreatedAt = time.Now().UTC()
+	person.Version = 1
+
+	r.persons[person.Id] = *person
+
+	return nil
+}
+
+func (r *PersonRepo) Delete(_ context.Context, id int, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkVersion(id, version); err != nil {
+		return fmt.Errorf("can't delete person: %w", err)
+	}
+
+	stored := r.persons[id]
+
+	deletedAt := time.Now().UTC()
+	stored.DeletedAt = &deletedAt
+	stored.Version++
+
+	r.persons[id] = stored
+
+	return nil
+}
+
+func (r *PersonRepo) GetByID(_ context.Context, id int, includeDeleted bool) (*app.Person, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	person, ok := r.persons[id]
+	if !ok || person.DeletedAt != nil && !includeDeleted {
+		return nil, fmt.Errorf("%w: person with ID %d doesn't exist", app.ErrNotFound, id)
+	}
+
+	return &person, nil
+}
+
+func (r *PersonRepo) GetByIDs(_ context.Context, ids []int, includeDeleted bool) ([]app.Person, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	persons := make([]app.Person, 0, len(ids))
+
+	for _, id := range ids {
+		if person, ok := r.persons[id]; ok && (person.DeletedAt == nil || includeDeleted) {
+			persons = append(persons, person)
+		}
+	}
+
+	return persons, nil
+}
+
+// StoreBatch, UpdateBatch and DeleteBatch check every item before changing anything,
+// so a failed batch leaves the repository as it was.
+func (r *PersonRepo) StoreBatch(_ context.Context, persons []app.Person) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	emails := make(map[string]bool, len(persons))
+
+	for i, per := range persons {
+		if emails[per.Email] || r.emailTaken(per.Email, 0) {
+			return &app.BatchItemError{
+				Index: i,
+				Err:   fmt.Errorf("%w: another person with email address %s already exists", app.ErrEmailConflict, per.Email),
+			}
+		}
+
+		emails[per.Email] = true
+	}
+
+	now := time.Now().UTC()
+
+	for i := range persons {
+		r.lastID++
+
+		persons[i].Id = r.lastID
+		persons[i].CreatedAt = now
+		persons[i].Version = 1
+		persons[i].DeletedAt = nil
+
+		r.persons[persons[i].Id] = persons[i]
+	}
+
+	return nil
+}
+
+func (r *PersonRepo) UpdateBatch(_ context.Context, persons []app.Person) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, per := range persons {
+		if err := r.checkVersion(per.Id, per.Version); err != nil {
+			return &app.BatchItemError{Index: i, Err: err}
+		}
+
+		if r.emailTaken(per.Email, per.Id) {
+			return &app.BatchItemError{
+				Index: i,
+				Err:   fmt.Errorf("%w: another person already using this email address: %s", app.ErrEmailConflict, per.Email),
+			}
+		}
+	}
+
+	for i, per := range persons {
+		stored := r.persons[per.Id]
+
+		stored.Email = per.Email
+		stored.Phone = per.Phone
+		stored.FirstName = per.FirstName
+		stored.LastName = per.LastName
+		stored.Version++
+
+		r.persons[per.Id] = stored
+		persons[i] = stored
+	}
+
+	return nil
+}
+
+func (r *PersonRepo) DeleteBatch(_ context.Context, refs []app.PersonRef) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, ref := range refs {
+		if err := r.checkVersion(ref.ID, ref.Version); err != nil {
+			return &app.BatchItemError{Index: i, Err: err}
+		}
+	}
+
+	deletedAt := time.Now().UTC()
+
+	for _, ref := range refs {
+		stored := r.persons[ref.ID]
+
+		stored.DeletedAt = &deletedAt
+		stored.Version++
+
+		r.persons[ref.ID] = stored
+	}
+
+	return nil
+}
+
+func (r *PersonRepo) GetByEmail(_ context.Context, email string, id int) (*app.Person, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, person := range r.persons {
+		if person.Email == email && person.Id != id && person.DeletedAt == nil {
+			return &person, nil
+		}
+	}
+
+	return &app.Person{}, nil
+}
+
+// GetByEmailForUpdate needs no lock of its own, the transactions are run one at a time.
+func (r *PersonRepo) GetByEmailForUpdate(ctx context.Context, email string) (*app.Person, error) {
+	return r.GetByEmail(ctx, email, 0)
+}
+
+func (r *PersonRepo) Update(_ context.Context, per *app.Person) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkVersion(per.Id, per.Version); err != nil {
+		return fmt.Errorf("can't update person: %w", err)
+	}
+
+	stored := r.persons[per.Id]
+
+	if r.emailTaken(per.Email, per.Id) {
+		return fmt.Errorf("%w: another person already using this email address: %s", app.ErrEmailConflict, per.Email)
+	}
+
+	stored.Email = per.Email
+	stored.Phone = per.Phone
+	stored.FirstName = per.FirstName
+	stored.LastName = per.LastName
+	stored.Version++
+
+	r.persons[per.Id] = stored
+	*per = stored
+
+	return nil
+}
+
+func (r *PersonRepo) Upsert(_ context.Context, per *app.Person) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, stored := range r.persons {
+		if stored.Email != per.Email || stored.DeletedAt != nil {
+			continue
+		}
+
+		stored.Phone = per.Phone
+		stored.FirstName = per.FirstName
+		stored.LastName = per.LastName
+		stored.Version++
+
+		r.persons[id] = stored
+		*per = stored
+
+		return false, nil
+	}
+
+	r.lastID++
+
+	per.Id = r.lastID
+	per.CreatedAt = time.Now().UTC()
+	per.Version = 1
+
+	r.persons[per.Id] = *per
+
+	return true, nil
+}
+
+func (r *PersonRepo) Patch(_ context.Context, id int, version int, patch app.PersonPatch) (*app.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkVersion(id, version); err != nil {
+		return nil, fmt.Errorf("can't patch person: %w", err)
+	}
+
+	stored := r.persons[id]
+
+	if patch.Email != nil && r.emailTaken(*patch.Email, id) {
+		return nil, fmt.Errorf("%w: another person already using this email address: %s", app.ErrEmailConflict, *patch.Email)
+	}
+
+	patch.Apply(&stored)
+	stored.Version++
+
+	r.persons[id] = stored
+
+	return &stored, nil
+}
+
+func (r *PersonRepo) Restore(_ context.Context, id int, version int) (*app.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.persons[id]
+
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("can't restore person: %w: person with ID %d doesn't exist", app.ErrNotFound, id)
+	case stored.DeletedAt == nil:
+		return nil, fmt.Errorf("can't restore person: %w: person with ID %d", app.ErrNotDeleted, id)
+	case stored.Version != version:
+		return nil, fmt.Errorf("can't restore person: %w: expected version %d, current is %d",
+			app.ErrVersionMismatch, version, stored.Version)
+	}
+
+	if r.emailTaken(stored.Email, id) {
+		return nil, fmt.Errorf("%w: email address of person with ID %d has been taken since deletion", app.ErrEmailConflict, id)
+	}
+
+	stored.DeletedAt = nil
+	stored.Version++
+
+	r.persons[id] = stored
+
+	return &stored, nil
+}
+
+func (r *PersonRepo) Purge(_ context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+
+	for id, person := range r.persons {
+		if person.DeletedAt != nil && person.DeletedAt.Before(deletedBefore) {
+			delete(r.persons, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (r *PersonRepo) GetPersonList(_ context.Context, query app.ListQuery) ([]app.Person, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	personList := make([]app.Person, 0, query.Limit)
+
+	for _, person := range r.persons {
+		if matchesFilter(&person, query.Filter) {
+			personList = append(personList, person)
+		}
+	}
+
+	less := func(a, b *app.Person) bool {
+		va, vb := sortValue(a, query.Sort.Field), sortValue(b, query.Sort.Field)
+		if va != vb {
+			return (va < vb) != query.Sort.Desc
+		}
+
+		return (a.Id < b.Id) != query.Sort.Desc
+	}
+
+	sort.Slice(personList, func(i, j int) bool {
+		return less(&personList[i], &personList[j])
+	})
+
+	if after := query.After; after != nil {
+		last := app.Person{Id: after.ID}
+
+		switch query.Sort.Field {
+		case app.SortByEmail:
+			last.Email = after.Value
+		case app.SortByFirstName:
+			last.FirstName = after.Value
+		case app.SortByLastName:
+			last.LastName = after.Value
+		case app.SortByCreatedAt:
+			t, err := time.Parse(time.RFC3339Nano, after.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: malformed cursor", app.ErrValidation)
+			}
+
+			last.CreatedAt = t
+		}
+
+		i := sort.Search(len(personList), func(i int) bool {
+			return less(&last, &personList[i])
+		})
+
+		personList = personList[i:]
+	}
+
+	if len(personList) > query.Limit {
+		personList = personList[:query.Limit]
+	}
+
+	return personList, nil
+}
+
+// Search ranks persons by the share of the query trigrams found in their names and email.
+// It approximates the word_similarity of pg_trgm closely enough for tests.
+func (r *PersonRepo) Search(_ context.Context, query string, limit int) ([]app.PersonMatch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	const threshold = 0.6
+
+	queryTrgm := trigrams(query)
+	if len(queryTrgm) == 0 {
+		return []app.PersonMatch{}, nil
+	}
+
+	matches := make([]app.PersonMatch, 0, limit)
+
+	for _, person := range r.persons {
+		if person.DeletedAt != nil {
+			continue
+		}
+
+		docTrgm := trigrams(person.FirstName + " " + person.LastName + " " + person.Email)
+
+		shared := 0
+		for t := range queryTrgm {
+			if docTrgm[t] {
+				shared++
+			}
+		}
+
+		score := float64(shared) / float64(len(queryTrgm))
+		if score >= threshold {
+			matches = append(matches, app.PersonMatch{Person: person, Score: score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+
+		return matches[i].Id < matches[j].Id
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// checkVersion checks that a live person with the given version exists.
+func (r *PersonRepo) checkVersion(id int, version int) error {
+	stored, ok := r.persons[id]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("%w: person with ID %d doesn't exist", app.ErrNotFound, id)
+	}
+
+	if stored.Version != version {
+		return fmt.Errorf("%w: expected version %d, current is %d", app.ErrVersionMismatch, version, stored.Version)
+	}
+
+	return nil
+}
+
+func (r *PersonRepo) emailTaken(email string, id int) bool {
+	for _, person := range r.persons {
+		if person.Email == email && person.Id != id && person.DeletedAt == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesFilter(p *app.Person, f app.PersonFilter) bool {
+	switch {
+	case p.DeletedAt != nil && !f.IncludeDeleted:
+		return false
+	case f.Email != "" && p.Email != f.Email:
+		return false
+	case f.PhonePrefix != "" && !strings.HasPrefix(p.Phone, f.PhonePrefix):
+		return false
+	case f.FirstName != "" && !containsFold(p.FirstName, f.FirstName):
+		return false
+	case f.LastName != "" && !containsFold(p.LastName, f.LastName):
+		return false
+	case !f.CreatedAfter.IsZero() && !p.CreatedAt.After(f.CreatedAfter):
+		return false
+	case !f.CreatedBefore.IsZero() && !p.CreatedAt.Before(f.CreatedBefore):
+		return false
+	}
+
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func sortValue(p *app.Person, field app.SortField) string {
+	if field == app.SortByCreatedAt {
+		// Fixed width, so that string order is time order.
+		return p.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000")
+	}
+
+	return field.ValueOf(p)
+}
+
+// trigrams splits s into words of letters and digits and returns
+// their trigrams the way pg_trgm does: lowercased and padded with spaces.
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+
+	return set
+}
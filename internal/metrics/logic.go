@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+)
+
+// PersonLogic is an app.PersonLogic counting the persons created and deleted by the wrapped one.
+// The changes are counted once they are done, so rolled back transactions don't count.
+type PersonLogic struct {
+	app.PersonLogic
+	metrics *Metrics
+}
+
+func NewPersonLogic(logic app.PersonLogic, m *Metrics) *PersonLogic {
+	return &PersonLogic{PersonLogic: logic, metrics: m}
+}
+
+func (l *PersonLogic) StorePerson(ctx context.Context, per *app.Person) error {
+	if err := l.PersonLogic.StorePerson(ctx, per); err != nil {
+		return err
+	}
+
+	l.metrics.personsCreated.Inc()
+
+	return nil
+}
+
+func (l *PersonLogic) DeletePerson(ctx context.Context, id int, version int) error {
+	if err := l.PersonLogic.DeletePerson(ctx, id, version); err != nil {
+		return err
+	}
+
+	l.metrics.personsDeleted.Inc()
+
+	return nil
+}
+
+func (l *PersonLogic) Upsert(ctx context.Context, per *app.Person) (bool, error) {
+	created, err := l.PersonLogic.Upsert(ctx, per)
+	if created {
+		l.metrics.personsCreated.Inc()
+	}
+
+	return created, err
+}
+
+func (l *PersonLogic) ImportPerson(ctx context.Context, per *app.Person, opts app.ImportOptions) (app.ImportOutcome, error) {
+	outcome, err := l.PersonLogic.ImportPerson(ctx, per, opts)
+	if err == nil && outcome == app.ImportCreated && !opts.DryRun {
+		l.metrics.personsCreated.Inc()
+	}
+
+	return outcome, err
+}
+
+func (l *PersonLogic) StorePersons(ctx context.Context, persons []app.Person, mode app.BulkMode) ([]app.BulkResult, error) {
+	results, err := l.PersonLogic.StorePersons(ctx, persons, mode)
+	l.metrics.personsCreated.Add(float64(succeeded(results)))
+
+	return results, err
+}
+
+func (l *PersonLogic) DeletePersons(ctx context.Context, refs []app.PersonRef, mode app.BulkMode) ([]app.BulkResult, error) {
+	results, err := l.PersonLogic.DeletePersons(ctx, refs, mode)
+	l.metrics.personsDeleted.Add(float64(succeeded(results)))
+
+	return results, err
+}
+
+func succeeded(results []app.BulkResult) int {
+	n := 0
+
+	for _, res := range results {
+		if res.Err == nil {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app/mock"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logic"
+	"github.com/EgorMamoshkin/person-api-crud/internal/memory"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestInstrumentation(t *testing.T) {
+	m := New()
+	repo := NewPersonRepo(memory.NewPersonRepo(), m)
+	perLogic := NewPersonLogic(logic.NewPersonLogic(repo, time.Second, ""), m)
+	ctx := context.Background()
+
+	per := &app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"}
+	require.NoError(t, perLogic.StorePerson(ctx, per))
+
+	_, err := perLogic.GetPersonByID(ctx, per.Id+1, false)
+	require.ErrorIs(t, err, app.ErrNotFound)
+
+	results, err := perLogic.DeletePersons(ctx, []app.PersonRef{{ID: per.Id, Version: per.Version}, {ID: per.Id + 1}}, app.BulkPerItem)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.personsCreated))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.personsDeleted))
+
+	// Missing persons are outcomes of the requests, not failures of the repository.
+	require.Zero(t, testutil.ToFloat64(m.queryErrors.WithLabelValues("GetByID")))
+	require.Zero(t, testutil.ToFloat64(m.queryErrors.WithLabelValues("WithinTx")))
+	require.Zero(t, testutil.ToFloat64(m.queryErrors.WithLabelValues("Store")))
+}
+
+func TestPersonRepo_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	m := New()
+
+	inner := mock_app.NewMockPersonRepository(ctrl)
+	repo := NewPersonRepo(inner, m)
+
+	inner.EXPECT().GetByID(ctx, 1, false).Return(nil, app.ErrNotFound)
+	inner.EXPECT().GetByID(ctx, 2, false).Return(nil, app.ErrUnavailable)
+	inner.EXPECT().Store(ctx, gomock.Any()).Return(app.ErrUnavailable)
+	gomock.InOrder(
+		inner.EXPECT().WithinTx(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, fn func(app.PersonRepository) error) error {
+			return fn(inner)
+		}),
+		inner.EXPECT().WithinTx(ctx, gomock.Any()).Return(app.ErrConcurrentUpdate),
+	)
+
+	_, err := repo.GetByID(ctx, 1, false)
+	require.ErrorIs(t, err, app.ErrNotFound)
+
+	_, err = repo.GetByID(ctx, 2, false)
+	require.ErrorIs(t, err, app.ErrUnavailable)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.queryErrors.WithLabelValues("GetByID")), "only unexpected errors must be counted")
+
+	err = repo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		return repo.Store(ctx, &app.Person{})
+	})
+	require.ErrorIs(t, err, app.ErrUnavailable)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.queryErrors.WithLabelValues("Store")))
+	require.Zero(t, testutil.ToFloat64(m.queryErrors.WithLabelValues("WithinTx")), "errors of the calls made in a transaction must be counted once")
+
+	err = repo.WithinTx(ctx, func(repo app.PersonRepository) error { return nil })
+	require.ErrorIs(t, err, app.ErrConcurrentUpdate)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.queryErrors.WithLabelValues("WithinTx")))
+}
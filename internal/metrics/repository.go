@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"time"
+)
+
+// PersonRepo is an app.PersonRepository measuring the duration and the errors of every call to the wrapped one.
+type PersonRepo struct {
+	repo    app.PersonRepository
+	metrics *Metrics
+}
+
+func NewPersonRepo(repo app.PersonRepository, m *Metrics) *PersonRepo {
+	return &PersonRepo{repo: repo, metrics: m}
+}
+
+func (r *PersonRepo) Store(ctx context.Context, person *app.Person) error {
+	start := time.Now()
+	err := r.repo.Store(ctx, person)
+	r.metrics.observeCall("Store", start, err)
+
+	return err
+}
+
+func (r *PersonRepo) Delete(ctx context.Context, id int, version int) error {
+	start := time.Now()
+	err := r.repo.Delete(ctx, id, version)
+	r.metrics.observeCall("Delete", start, err)
+
+	return err
+}
+
+func (r *PersonRepo) GetByID(ctx context.Context, id int, includeDeleted bool) (*app.Person, error) {
+	start := time.Now()
+	person, err := r.repo.GetByID(ctx, id, includeDeleted)
+	r.metrics.observeCall("GetByID", start, err)
+
+	return person, err
+}
+
+func (r *PersonRepo) GetByIDs(ctx context.Context, ids []int, includeDeleted bool) ([]app.Person, error) {
+	start := time.Now()
+	persons, err := r.repo.GetByIDs(ctx, ids, includeDeleted)
+	r.metrics.observeCall("GetByIDs", start, err)
+
+	return persons, err
+}
+
+func (r *PersonRepo) GetByEmail(ctx context.Context, email string, id int) (*app.Person, error) {
+	start := time.Now()
+	person, err := r.repo.GetByEmail(ctx, email, id)
+	r.metrics.observeCall("GetByEmail", start, err)
+
+	return person, err
+}
+
+func (r *PersonRepo) GetByEmailForUpdate(ctx context.Context, email string) (*app.Person, error) {
+	start := time.Now()
+	person, err := r.repo.GetByEmailForUpdate(ctx, email)
+	r.metrics.observeCall("GetByEmailForUpdate", start, err)
+
+	return person, err
+}
+
+func (r *PersonRepo) Update(ctx context.Context, person *app.Person) error {
+	start := time.Now()
+	err := r.repo.Update(ctx, person)
+	r.metrics.observeCall("Update", start, err)
+
+	return err
+}
+
+func (r *PersonRepo) Upsert(ctx context.Context, person *app.Person) (bool, error) {
+	start := time.Now()
+	created, err := r.repo.Upsert(ctx, person)
+	r.metrics.observeCall("Upsert", start, err)
+
+	return created, err
+}
+
+func (r *PersonRepo) Patch(ctx context.Context, id int, version int, patch app.PersonPatch) (*app.Person, error) {
+	start := time.Now()
+	person, err := r.repo.Patch(ctx, id, version, patch)
+	r.metrics.observeCall("Patch", start, err)
+
+	return person, err
+}
+
+func (r *PersonRepo) GetPersonList(ctx context.Context, query app.ListQuery) ([]app.Person, error) {
+	start := time.Now()
+	persons, err := r.repo.GetPersonList(ctx, query)
+	r.metrics.observeCall("GetPersonList", start, err)
+
+	return persons, err
+}
+
+func (r *PersonRepo) Search(ctx context.Context, query string, limit int) ([]app.PersonMatch, error) {
+	start := time.Now()
+	matches, err := r.repo.Search(ctx, query, limit)
+	r.metrics.observeCall("Search", start, err)
+
+	return matches, err
+}
+
+func (r *PersonRepo) Restore(ctx context.Context, id int, version int) (*app.Person, error) {
+	start := time.Now()
+	person, err := r.repo.Restore(ctx, id, version)
+	r.metrics.observeCall("Restore", start, err)
+
+	return person, err
+}
+
+func (r *PersonRepo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	start := time.Now()
+	purged, err := r.repo.Purge(ctx, deletedBefore)
+	r.metrics.observeCall("Purge", start, err)
+
+	return purged, err
+}
+
+func (r *PersonRepo) StoreBatch(ctx context.Context, persons []app.Person) error {
+	start := time.Now()
+	err := r.repo.StoreBatch(ctx, persons)
+	r.metrics.observeCall("StoreBatch", start, err)
+
+	return err
+}
+
+func (r *PersonRepo) UpdateBatch(ctx context.Context, persons []app.Person) error {
+	start := time.Now()
+	err := r.repo.UpdateBatch(ctx, persons)
+	r.metrics.observeCall("UpdateBatch", start, err)
+
+	return err
+}
+
+func (r *PersonRepo) DeleteBatch(ctx context.Context, refs []app.PersonRef) error {
+	start := time.Now()
+	err := r.repo.DeleteBatch(ctx, refs)
+	r.metrics.observeCall("DeleteBatch", start, err)
+
+	return err
+}
+
+func (r *PersonRepo) AddAudit(ctx context.Context, recs ...*app.AuditRecord) error {
+	start := time.Now()
+	err := r.repo.AddAudit(ctx, recs...)
+	r.metrics.observeCall("AddAudit", start, err)
+
+	return err
+}
+
+func (r *PersonRepo) GetHistory(ctx context.Context, personID int) ([]app.AuditRecord, error) {
+	start := time.Now()
+	history, err := r.repo.GetHistory(ctx, personID)
+	r.metrics.observeCall("GetHistory", start, err)
+
+	return history, err
+}
+
+// WithinTx measures the whole transaction, retries included, as well as the calls made in it.
+// Errors returned by fn are left to the calls made in it, only the ones of the transaction itself are counted.
+func (r *PersonRepo) WithinTx(ctx context.Context, fn func(repo app.PersonRepository) error) error {
+	var fnErr error
+
+	start := time.Now()
+	err := r.repo.WithinTx(ctx, func(repo app.PersonRepository) error {
+		fnErr = fn(NewPersonRepo(repo, r.metrics))
+
+		return fnErr
+	})
+
+	txErr := err
+	if fnErr != nil && errors.Is(err, fnErr) {
+		txErr = nil
+	}
+
+	r.metrics.observeCall("WithinTx", start, txErr)
+
+	return err
+}
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad_Embedded(t *testing.T) {
+	migrations, err := Load(embedded)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		require.Equal(t, i+1, m.Version, "migration versions must be contiguous")
+		require.NotEmpty(t, m.Up)
+		require.NotEmpty(t, m.Down)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	testTable := []struct {
+		name        string
+		files       fstest.MapFS
+		expected    []Migration
+		expectedErr string
+	}{
+		{
+			name: "OK",
+			files: fstest.MapFS{
+				"migrations/0002_second.up.sql":   {Data: []byte("up 2")},
+				"migrations/0002_second.down.sql": {Data: []byte("down 2")},
+				"migrations/0001_first.up.sql":    {Data: []byte("up 1")},
+				"migrations/0001_first.down.sql":  {Data: []byte("down 1")},
+			},
+			expected: []Migration{
+				{Version: 1, Name: "first", Up: "up 1", Down: "down 1"},
+				{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+			},
+		}, {
+			name: "Missing Down",
+			files: fstest.MapFS{
+				"migrations/0001_first.up.sql": {Data: []byte("up 1")},
+			},
+			expectedErr: "migration 1_first must have both up and down files",
+		}, {
+			name: "Invalid Name",
+			files: fstest.MapFS{
+				"migrations/first.sql": {Data: []byte("up 1")},
+			},
+			expectedErr: "invalid migration file name: migrations/first.sql",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			migrations, err := Load(testCase.files)
+
+			if testCase.expectedErr != "" {
+				require.EqualError(t, err, testCase.expectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, migrations)
+		})
+	}
+}
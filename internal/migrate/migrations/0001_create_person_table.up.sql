@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS person (
+    id         SERIAL PRIMARY KEY,
+    email      TEXT NOT NULL,
+    phone      TEXT NOT NULL,
+    first_name TEXT NOT NULL,
+    last_name  TEXT NOT NULL
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS person_email_key;
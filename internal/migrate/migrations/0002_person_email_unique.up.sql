@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS person_email_key ON person (email);
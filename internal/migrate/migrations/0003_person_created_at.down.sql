@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS person_phone_pattern_idx;
+DROP INDEX IF EXISTS person_first_name_idx;
+DROP INDEX IF EXISTS person_last_name_idx;
+DROP INDEX IF EXISTS person_created_at_idx;
+
+ALTER TABLE person DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,6 @@
+ALTER TABLE person ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS person_created_at_idx ON person (created_at, id);
+CREATE INDEX IF NOT EXISTS person_last_name_idx ON person (last_name, id);
+CREATE INDEX IF NOT EXISTS person_first_name_idx ON person (first_name, id);
+CREATE INDEX IF NOT EXISTS person_phone_pattern_idx ON person (phone text_pattern_ops);
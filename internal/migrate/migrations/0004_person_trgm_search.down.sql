@@ -0,0 +1 @@
+DROP INDEX IF EXISTS person_search_trgm_idx;
@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS person_search_trgm_idx ON person
+    USING gin ((first_name || ' ' || last_name || ' ' || email) gin_trgm_ops);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS person_deleted_at_idx;
+
+-- Deleted persons may share emails with live ones, which the full unique index doesn't allow.
+DELETE FROM person WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS person_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS person_email_key ON person (email);
+
+ALTER TABLE person DROP COLUMN IF EXISTS deleted_at;
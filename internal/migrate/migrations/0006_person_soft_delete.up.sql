@@ -0,0 +1,6 @@
+ALTER TABLE person ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS person_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS person_email_key ON person (email) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS person_deleted_at_idx ON person (deleted_at) WHERE deleted_at IS NOT NULL;
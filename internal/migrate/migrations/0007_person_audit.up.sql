@@ -0,0 +1,12 @@
+-- Audit records outlive purged persons, so there is no foreign key to person.
+CREATE TABLE IF NOT EXISTS person_audit (
+    id         BIGSERIAL PRIMARY KEY,
+    person_id  INTEGER     NOT NULL,
+    action     TEXT        NOT NULL,
+    actor      TEXT        NOT NULL,
+    request_id TEXT        NOT NULL DEFAULT '',
+    changes    JSONB       NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX IF NOT EXISTS person_audit_person_id_idx ON person_audit (person_id, id);
@@ -0,0 +1,5 @@
+-- Emails are stored lowercased, so that the unique index tells them apart regardless of case.
+-- Live persons whose emails differ only in case make it fail, they have to be merged by hand first.
+UPDATE person SET email = lower(email) WHERE email <> lower(email);
+
+ALTER TABLE person ADD CONSTRAINT person_email_lowercase CHECK (email = lower(email));
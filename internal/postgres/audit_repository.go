@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"time"
+)
+
+// auditRow is an app.AuditRecord as stored in the person_audit table, with changes kept as JSONB.
+type auditRow struct {
+	ID        int64
+	PersonID  int
+	Action    string
+	Actor     string
+	RequestID string
+	Changes   []byte
+	CreatedAt time.Time
+}
+
+func (r *PSQLRepo) AddAudit(ctx context.Context, recs ...*app.AuditRecord) error {
+	if len(recs) == 0 {
+		return nil
+	}
+
+	stmt := r.runner.InsertInto("person_audit").
+		Columns("person_id", "action", "actor", "request_id", "changes")
+
+	for _, rec := range recs {
+		changes, err := json.Marshal(rec.Changes)
+		if err != nil {
+			return fmt.Errorf("can't encode audit changes: %w", err)
+		}
+
+		stmt.Values(rec.PersonID, string(rec.Action), rec.Actor, rec.RequestID, string(changes))
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("can't save audit records: %w", dbError(err))
+	}
+
+	return nil
+}
+
+func (r *PSQLRepo) GetHistory(ctx context.Context, personID int) ([]app.AuditRecord, error) {
+	var rows []auditRow
+
+	_, err := r.runner.Select("*").From("person_audit").
+		Where("person_id = ?", personID).
+		OrderAsc("id").LoadContext(ctx, &rows)
+
+	if err != nil {
+		return nil, fmt.Errorf("can't get person history: %w", dbError(err))
+	}
+
+	history := make([]app.AuditRecord, 0, len(rows))
+
+	for _, row := range rows {
+		rec := app.AuditRecord{
+			ID:        row.ID,
+			PersonID:  row.PersonID,
+			Action:    app.AuditAction(row.Action),
+			Actor:     row.Actor,
+			RequestID: row.RequestID,
+			CreatedAt: row.CreatedAt,
+		}
+
+		if err := json.Unmarshal(row.Changes, &rec.Changes); err != nil {
+			return nil, fmt.Errorf("can't decode changes of audit record %d: %w", row.ID, err)
+		}
+
+		history = append(history, rec)
+	}
+
+	return history, nil
+}
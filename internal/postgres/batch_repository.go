@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/lib/pq"
+	"regexp"
+	"strings"
+)
+
+func (r *PSQLRepo) GetByIDs(ctx context.Context, ids []int, includeDeleted bool) ([]app.Person, error) {
+	persons := make([]app.Person, 0, len(ids))
+
+	if len(ids) == 0 {
+		return persons, nil
+	}
+
+	stmt := r.runner.Select("*").From("person").Where("id IN ?", ids)
+
+	if !includeDeleted {
+		stmt.Where("deleted_at IS NULL")
+	}
+
+	if _, err := stmt.LoadContext(ctx, &persons); err != nil {
+		return nil, fmt.Errorf("can't get persons: %w", dbError(err))
+	}
+
+	return persons, nil
+}
+
+// StoreBatch inserts persons with a single multi-row INSERT.
+// Their emails must be distinct, the stored rows are matched with the given persons by them.
+func (r *PSQLRepo) StoreBatch(ctx context.Context, persons []app.Person) error {
+	if len(persons) == 0 {
+		return nil
+	}
+
+	stmt := r.runner.InsertInto("person").
+		Columns("email", "phone", "first_name", "last_name")
+
+	for i := range persons {
+		stmt.Record(&persons[i])
+	}
+
+	var stored []app.Person
+
+	err := stmt.Returning(personColumns...).LoadContext(ctx, &stored)
+
+	if isUniqueViolation(err) {
+		return emailConflict(err, persons)
+	}
+
+	if err != nil {
+		return fmt.Errorf("can't save persons: %w", dbError(err))
+	}
+
+	byEmail := make(map[string]app.Person, len(stored))
+	for _, person := range stored {
+		byEmail[person.Email] = person
+	}
+
+	for i := range persons {
+		persons[i] = byEmail[persons[i].Email]
+	}
+
+	return nil
+}
+
+// UpdateBatch updates persons with a single UPDATE joined with the list of their new values.
+func (r *PSQLRepo) UpdateBatch(ctx context.Context, persons []app.Person) error {
+	if len(persons) == 0 {
+		return nil
+	}
+
+	rows := make([]string, 0, len(persons))
+	args := make([]interface{}, 0, 6*len(persons))
+
+	for _, per := range persons {
+		rows = append(rows, "(?, ?, ?, ?, ?, ?)")
+		args = append(args, per.Id, per.Version, per.Email, per.Phone, per.FirstName, per.LastName)
+	}
+
+	var updated []app.Person
+
+	err := r.runner.UpdateBySql(
+		"UPDATE person AS p SET email = v.email, phone = v.phone, first_name = v.first_name, last_name = v.last_name,"+
+			" version = p.version + 1"+
+			" FROM (VALUES "+strings.Join(rows, ", ")+") AS v (id, version, email, phone, first_name, last_name)"+
+			" WHERE p.id = v.id AND p.version = v.version AND p.deleted_at IS NULL"+
+			" RETURNING p.*",
+		args...,
+	).LoadContext(ctx, &updated)
+
+	if isUniqueViolation(err) {
+		return emailConflict(err, persons)
+	}
+
+	if err != nil {
+		return fmt.Errorf("can't update persons: %w", dbError(err))
+	}
+
+	byID := make(map[int]app.Person, len(updated))
+	for _, person := range updated {
+		byID[person.Id] = person
+	}
+
+	for i, per := range persons {
+		person, ok := byID[per.Id]
+		if !ok {
+			return &app.BatchItemError{Index: i, Err: r.missingOrStale(ctx, per.Id, per.Version, false)}
+		}
+
+		persons[i] = person
+	}
+
+	return nil
+}
+
+// DeleteBatch marks persons deleted the way Delete does.
+func (r *PSQLRepo) DeleteBatch(ctx context.Context, refs []app.PersonRef) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	tuples := make([]string, 0, len(refs))
+	args := make([]interface{}, 0, 2*len(refs))
+
+	for _, ref := range refs {
+		tuples = append(tuples, "(?, ?)")
+		args = append(args, ref.ID, ref.Version)
+	}
+
+	var deleted []int
+
+	err := r.runner.UpdateBySql(
+		"UPDATE person SET deleted_at = now(), version = version + 1"+
+			" WHERE (id, version) IN ("+strings.Join(tuples, ", ")+") AND deleted_at IS NULL"+
+			" RETURNING id",
+		args...,
+	).LoadContext(ctx, &deleted)
+
+	if err != nil {
+		return fmt.Errorf("can't delete persons: %w", dbError(err))
+	}
+
+	isDeleted := make(map[int]bool, len(deleted))
+	for _, id := range deleted {
+		isDeleted[id] = true
+	}
+
+	for i, ref := range refs {
+		if !isDeleted[ref.ID] {
+			return &app.BatchItemError{Index: i, Err: r.missingOrStale(ctx, ref.ID, ref.Version, false)}
+		}
+	}
+
+	return nil
+}
+
+var conflictingEmailRe = regexp.MustCompile(`^Key \(email\)=\((.*)\) already exists\.$`)
+
+// emailConflict finds the person whose email has violated the unique index.
+func emailConflict(err error, persons []app.Person) error {
+	var (
+		email string
+		pqErr *pq.Error
+	)
+
+	if errors.As(err, &pqErr) {
+		if m := conflictingEmailRe.FindStringSubmatch(pqErr.Detail); m != nil {
+			email = m[1]
+		}
+	}
+
+	for i, per := range persons {
+		if per.Email == email {
+			return &app.BatchItemError{
+				Index: i,
+				Err:   fmt.Errorf("%w: another person with email address %s already exists", app.ErrEmailConflict, email),
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: %s", app.ErrEmailConflict, err)
+}
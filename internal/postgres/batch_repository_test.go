@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPSQLRepo_Batch(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	persons := []app.Person{
+		{Email: fmt.Sprintf("batch-1-%d@example.com", suffix), Phone: "+1111111111", FirstName: "Test", LastName: "One"},
+		{Email: fmt.Sprintf("batch-2-%d@example.com", suffix), Phone: "+2222222222", FirstName: "Test", LastName: "Two"},
+	}
+
+	t.Cleanup(func() {
+		_, err := repo.session.DeleteFrom("person").
+			Where("email IN ?", []string{persons[0].Email, persons[1].Email}).ExecContext(ctx)
+		require.NoError(t, err)
+	})
+
+	require.NoError(t, repo.StoreBatch(ctx, persons))
+	require.NotZero(t, persons[0].Id)
+	require.NotZero(t, persons[1].Id)
+
+	duplicate := []app.Person{{Email: persons[1].Email, Phone: "+3333333333", FirstName: "Test", LastName: "Three"}}
+
+	var itemErr *app.BatchItemError
+
+	err := repo.StoreBatch(ctx, duplicate)
+	require.ErrorAs(t, err, &itemErr)
+	require.Equal(t, 0, itemErr.Index)
+	require.ErrorIs(t, err, app.ErrEmailConflict)
+
+	updated := append([]app.Person(nil), persons...)
+	updated[0].LastName = "First"
+	updated[1].LastName = "Second"
+
+	require.NoError(t, repo.UpdateBatch(ctx, updated))
+	require.Equal(t, "Second", updated[1].LastName)
+	require.Equal(t, persons[1].Version+1, updated[1].Version)
+
+	err = repo.DeleteBatch(ctx, []app.PersonRef{{ID: persons[0].Id, Version: updated[0].Version}, {ID: persons[1].Id, Version: persons[1].Version}})
+	require.ErrorAs(t, err, &itemErr)
+	require.Equal(t, 1, itemErr.Index)
+	require.ErrorIs(t, err, app.ErrVersionMismatch)
+}
+
+func TestEmailConflict(t *testing.T) {
+	persons := []app.Person{{Email: "a@example.com"}, {Email: "b@example.com"}}
+
+	var itemErr *app.BatchItemError
+
+	err := emailConflict(&pq.Error{Code: uniqueViolation, Detail: "Key (email)=(b@example.com) already exists."}, persons)
+	require.ErrorAs(t, err, &itemErr)
+	require.Equal(t, 1, itemErr.Index)
+	require.ErrorIs(t, err, app.ErrEmailConflict)
+
+	err = emailConflict(&pq.Error{Code: uniqueViolation}, persons)
+	require.False(t, errors.As(err, &itemErr))
+	require.ErrorIs(t, err, app.ErrEmailConflict)
+}
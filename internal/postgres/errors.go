@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/lib/pq"
+	"net"
+)
+
+const uniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+const queryCanceled = "57014"
+
+// dbError marks errors caused by the database being unreachable or overloaded with app.ErrUnavailable
+// and the ones of queries which haven't finished in time with app.ErrTimeout, so they are not reported
+// as server bugs. The errors are wrapped, so that their causes can still be matched too.
+func dbError(err error) error {
+	switch {
+	case isTimeout(err):
+		return &causedError{kind: app.ErrTimeout, err: err}
+	case isUnavailable(err):
+		return &causedError{kind: app.ErrUnavailable, err: err}
+	}
+
+	return err
+}
+
+// causedError is an app error caused by err, errors.Is and errors.As match both of them.
+type causedError struct {
+	kind error
+	err  error
+}
+
+func (e *causedError) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+func (e *causedError) Unwrap() error {
+	return e.err
+}
+
+func (e *causedError) Is(target error) bool {
+	return target == e.kind
+}
+
+// isTimeout tells whether the query has been interrupted by the deadline of its context or the statement timeout.
+func isTimeout(err error) bool {
+	var pqErr *pq.Error
+
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &pqErr) && pqErr.Code == queryCanceled)
+}
+
+func isUnavailable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", "53", "57":
+			return true
+		}
+	}
+
+	return false
+}
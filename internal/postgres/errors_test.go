@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDBError(t *testing.T) {
+	testTable := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "Bad Connection", err: driver.ErrBadConn, expected: app.ErrUnavailable},
+		{name: "Too Many Connections", err: &pq.Error{Code: "53300"}, expected: app.ErrUnavailable},
+		{name: "Deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), expected: app.ErrTimeout},
+		{name: "Statement Timeout", err: &pq.Error{Code: queryCanceled}, expected: app.ErrTimeout},
+		{name: "Unique Violation", err: &pq.Error{Code: uniqueViolation}},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := dbError(testCase.err)
+			require.ErrorIs(t, err, testCase.err, "the cause must still be matched")
+
+			if testCase.expected == nil {
+				require.Equal(t, testCase.err, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, testCase.expected)
+
+			var pqErr *pq.Error
+			require.Equal(t, errors.As(testCase.err, &pqErr), errors.As(err, &pqErr))
+		})
+	}
+
+	require.NotErrorIs(t, dbError(context.DeadlineExceeded), app.ErrUnavailable, "timeouts aren't outages")
+}
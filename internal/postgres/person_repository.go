@@ -2,66 +2,123 @@ package postgres
 
 import (
 	"context"
-	"errors"
+	"database/sql"
 	"fmt"
 	"github.com/EgorMamoshkin/person-api-crud/internal/app"
 	"github.com/gocraft/dbr/v2"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+	"strings"
 	"time"
 )
 
+var personColumns = []string{"id", "email", "phone", "first_name", "last_name", "created_at", "version", "deleted_at"}
+
 type PSQLRepo struct {
 	session *dbr.Session
+	// runner is the session itself or the transaction the repository is bound to by WithinTx.
+	runner dbr.SessionRunner
+	txOpts app.TxOptions
+}
+
+// PoolOptions configure the connection pool, zero durations are unlimited.
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ConnectTimeout limits checking the connection on start.
+	ConnectTimeout time.Duration
 }
 
-func NewPostgresRepo(dsn string) *PSQLRepo {
+func NewPostgresRepo(dsn string, pool PoolOptions, txOpts app.TxOptions) *PSQLRepo {
 	conn, err := dbr.Open("postgres", dsn, nil)
 	if err != nil {
 		logrus.Fatalf("failed to open a database : %s", err)
 	}
 
-	conn.SetMaxOpenConns(10)
+	conn.SetMaxOpenConns(pool.MaxOpenConns)
+	conn.SetMaxIdleConns(pool.MaxIdleConns)
+	conn.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	conn.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
 
-	sess := conn.NewSession(nil)
+	sess := conn.NewSession(&queryTracer{})
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), pool.ConnectTimeout)
 	defer cancel()
 
 	if err := sess.PingContext(ctx); err != nil {
 		logrus.Fatalf("can't connect to database: %s", err)
 	}
 
-	return &PSQLRepo{session: sess}
+	return &PSQLRepo{session: sess, runner: sess, txOpts: txOpts}
 }
 
-func (r *PSQLRepo) Store(ctx context.Context, person *app.Person) error {
+func (r *PSQLRepo) DB() *sql.DB {
+	return r.session.DB
+}
+
+// PoolStats are the connection pool statistics reported by the health check.
+type PoolStats struct {
+	MaxOpen   int    `json:"maxOpen"`
+	Open      int    `json:"open"`
+	InUse     int    `json:"inUse"`
+	Idle      int    `json:"idle"`
+	WaitCount int64  `json:"waitCount"`
+	WaitTime  string `json:"waitTime"`
+}
+
+// Check pings the database, it is the health check of the repository.
+func (r *PSQLRepo) Check(ctx context.Context) (interface{}, error) {
+	stats := r.session.Stats()
+
+	details := PoolStats{
+		MaxOpen:   stats.MaxOpenConnections,
+		Open:      stats.OpenConnections,
+		InUse:     stats.InUse,
+		Idle:      stats.Idle,
+		WaitCount: stats.WaitCount,
+		WaitTime:  stats.WaitDuration.String(),
+	}
 
-	_, err := r.session.InsertInto("person").
+	if err := r.session.PingContext(ctx); err != nil {
+		return details, fmt.Errorf("can't ping database: %w", err)
+	}
+
+	return details, nil
+}
+
+// Close closes the connection pool, waiting for the queries in progress.
+func (r *PSQLRepo) Close() error {
+	return r.session.Close()
+}
+
+func (r *PSQLRepo) Store(ctx context.Context, person *app.Person) error {
+	err := r.runner.InsertInto("person").
 		Columns("email", "phone", "first_name", "last_name").
-		Record(person).ExecContext(ctx)
+		Record(person).
+		Returning("id", "created_at", "version").LoadContext(ctx, person)
 
-	if err != nil {
-		return fmt.Errorf("can't save person: %w", err)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: another person with email address %s already exists", app.ErrEmailConflict, person.Email)
 	}
 
-	id, err := r.getID(ctx, person.Email)
 	if err != nil {
-		time.Sleep(2 * time.Second)
-
-		id, _ = r.getID(ctx, person.Email)
-		person.Id = id
-	} else {
-		person.Id = id
+		return fmt.Errorf("can't save person: %w", dbError(err))
 	}
 
 	return nil
 }
 
-func (r *PSQLRepo) Delete(ctx context.Context, id int) error {
-	res, err := r.session.DeleteFrom("person").Where("id = ?", id).ExecContext(ctx)
+// Delete marks the person deleted, it is removed for good by Purge once the retention period is over.
+func (r *PSQLRepo) Delete(ctx context.Context, id int, version int) error {
+	res, err := r.runner.Update("person").
+		Set("deleted_at", dbr.Expr("now()")).
+		IncrBy("version", 1).
+		Where("id = ? AND version = ? AND deleted_at IS NULL", id, version).ExecContext(ctx)
+
 	if err != nil {
-		return fmt.Errorf("can't delete person: %w", err)
+		return fmt.Errorf("can't delete person: %w", dbError(err))
 	}
 
 	rowsDeleted, err := res.RowsAffected()
@@ -70,24 +127,29 @@ func (r *PSQLRepo) Delete(ctx context.Context, id int) error {
 	}
 
 	if rowsDeleted == 0 {
-		return errors.New("can't delete. person doesn't exist")
+		return fmt.Errorf("can't delete person: %w", r.missingOrStale(ctx, id, version, false))
 	}
 
 	return nil
 }
 
-func (r *PSQLRepo) GetByID(ctx context.Context, id int) (*app.Person, error) {
+func (r *PSQLRepo) GetByID(ctx context.Context, id int, includeDeleted bool) (*app.Person, error) {
 	var person app.Person
 
-	res, err := r.session.Select("*").From("person").
-		Where("id = ?", id).LoadContext(ctx, &person)
+	stmt := r.runner.Select("*").From("person").Where("id = ?", id)
+
+	if !includeDeleted {
+		stmt.Where("deleted_at IS NULL")
+	}
+
+	res, err := stmt.LoadContext(ctx, &person)
 
 	if err != nil {
-		return nil, fmt.Errorf("can't get person: %w", err)
+		return nil, fmt.Errorf("can't get person: %w", dbError(err))
 	}
 
 	if res == 0 {
-		return nil, fmt.Errorf("person with ID %d doesn't exist", id)
+		return nil, fmt.Errorf("%w: person with ID %d doesn't exist", app.ErrNotFound, id)
 	}
 
 	return &person, nil
@@ -96,66 +158,302 @@ func (r *PSQLRepo) GetByID(ctx context.Context, id int) (*app.Person, error) {
 func (r *PSQLRepo) GetByEmail(ctx context.Context, email string, id int) (*app.Person, error) {
 	var person app.Person
 
-	_, err := r.session.Select("*").From("person").
-		Where("email = ? AND id <> ?", email, id).LoadContext(ctx, &person)
+	_, err := r.runner.Select("*").From("person").
+		Where("email = ? AND id <> ? AND deleted_at IS NULL", email, id).LoadContext(ctx, &person)
 
 	if err != nil {
-		return nil, fmt.Errorf("can't get person: %w", err)
+		return nil, fmt.Errorf("can't get person: %w", dbError(err))
 	}
 
 	return &person, nil
 }
 
-func (r *PSQLRepo) GetPersonList(ctx context.Context, id int, batchSize int) ([]app.Person, error) {
-	personList := make([]app.Person, 0, batchSize)
+func (r *PSQLRepo) GetByEmailForUpdate(ctx context.Context, email string) (*app.Person, error) {
+	var person app.Person
 
-	res, err := r.session.Select("*").From("person").
-		Where("id BETWEEN ? AND ?", id, id+batchSize-1).LoadContext(ctx, &personList)
+	_, err := r.runner.Select("*").From("person").
+		Where("email = ? AND deleted_at IS NULL", email).Suffix("FOR UPDATE").LoadContext(ctx, &person)
 
 	if err != nil {
-		return nil, fmt.Errorf("can't get person list: %w", err)
+		return nil, fmt.Errorf("can't get person: %w", dbError(err))
 	}
 
-	if res == 0 {
-		return nil, fmt.Errorf("person with range ID %d - %d doesn't exist", id, id+batchSize-1)
+	return &person, nil
+}
+
+func (r *PSQLRepo) GetPersonList(ctx context.Context, query app.ListQuery) ([]app.Person, error) {
+	personList := make([]app.Person, 0, query.Limit)
+
+	stmt, err := listStmt(r.runner.Select("*").From("person"), query)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = stmt.LoadContext(ctx, &personList)
+	if err != nil {
+		return nil, fmt.Errorf("can't get person list: %w", dbError(err))
 	}
 
 	return personList, nil
 }
 
 func (r *PSQLRepo) Update(ctx context.Context, per *app.Person) error {
-	res, err := r.session.Update("person").
+	var updated []app.Person
+
+	err := r.runner.Update("person").
 		Set("email", per.Email).
 		Set("phone", per.Phone).
 		Set("first_name", per.FirstName).
 		Set("last_name", per.LastName).
-		Where("id = ?", per.Id).ExecContext(ctx)
+		IncrBy("version", 1).
+		Where("id = ? AND version = ? AND deleted_at IS NULL", per.Id, per.Version).
+		Returning(personColumns...).LoadContext(ctx, &updated)
 
-	if err != nil {
-		return fmt.Errorf("can't update person: %w", err)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: another person already using this email address: %s", app.ErrEmailConflict, per.Email)
 	}
 
-	numRows, err := res.RowsAffected()
 	if err != nil {
-		return err
+		return fmt.Errorf("can't update person: %w", dbError(err))
 	}
 
-	if numRows != 1 {
-		return fmt.Errorf("updating error. Affected rows: %d", numRows)
+	if len(updated) == 0 {
+		return fmt.Errorf("can't update person: %w", r.missingOrStale(ctx, per.Id, per.Version, false))
 	}
 
+	*per = updated[0]
+
 	return nil
 }
 
-func (r *PSQLRepo) getID(ctx context.Context, email string) (int, error) {
-	var id int
+func (r *PSQLRepo) Upsert(ctx context.Context, per *app.Person) (bool, error) {
+	var upserted []struct {
+		app.Person
+		Inserted bool
+	}
+
+	// xmax of a row is zero unless it was locked or updated, that is the row was just inserted.
+	err := r.runner.InsertBySql(
+		"INSERT INTO person (email, phone, first_name, last_name) VALUES (?, ?, ?, ?)"+
+			" ON CONFLICT (email) WHERE deleted_at IS NULL DO UPDATE"+
+			" SET phone = excluded.phone, first_name = excluded.first_name, last_name = excluded.last_name,"+
+			" version = person.version + 1"+
+			" RETURNING *, xmax = 0 AS inserted",
+		per.Email, per.Phone, per.FirstName, per.LastName,
+	).LoadContext(ctx, &upserted)
+
+	if err != nil {
+		return false, fmt.Errorf("can't upsert person: %w", dbError(err))
+	}
+
+	if len(upserted) == 0 {
+		return false, fmt.Errorf("can't upsert person with email address %s", per.Email)
+	}
+
+	*per = upserted[0].Person
+
+	return upserted[0].Inserted, nil
+}
+
+func (r *PSQLRepo) Patch(ctx context.Context, id int, version int, patch app.PersonPatch) (*app.Person, error) {
+	var updated []app.Person
+
+	err := r.runner.Update("person").
+		SetMap(patchColumns(patch)).
+		IncrBy("version", 1).
+		Where("id = ? AND version = ? AND deleted_at IS NULL", id, version).
+		Returning(personColumns...).LoadContext(ctx, &updated)
+
+	if isUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: another person already using this email address: %s", app.ErrEmailConflict, *patch.Email)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can't patch person: %w", dbError(err))
+	}
+
+	if len(updated) == 0 {
+		return nil, fmt.Errorf("can't patch person: %w", r.missingOrStale(ctx, id, version, false))
+	}
+
+	return &updated[0], nil
+}
+
+func (r *PSQLRepo) Restore(ctx context.Context, id int, version int) (*app.Person, error) {
+	var restored []app.Person
 
-	res, err := r.session.Select("id").From("person").
-		Where("email = ?", email).LoadContext(ctx, &id)
+	err := r.runner.Update("person").
+		Set("deleted_at", nil).
+		IncrBy("version", 1).
+		Where("id = ? AND version = ? AND deleted_at IS NOT NULL", id, version).
+		Returning(personColumns...).LoadContext(ctx, &restored)
 
-	if err != nil || res == 0 {
-		return 0, fmt.Errorf("can't get ID: %w", err)
+	if isUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: email address of person with ID %d has been taken since deletion", app.ErrEmailConflict, id)
 	}
 
-	return id, nil
+	if err != nil {
+		return nil, fmt.Errorf("can't restore person: %w", dbError(err))
+	}
+
+	if len(restored) == 0 {
+		return nil, fmt.Errorf("can't restore person: %w", r.missingOrStale(ctx, id, version, true))
+	}
+
+	return &restored[0], nil
+}
+
+// Purge hard deletes persons soft deleted before the given time.
+func (r *PSQLRepo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	res, err := r.runner.DeleteFrom("person").
+		Where("deleted_at < ?", deletedBefore).ExecContext(ctx)
+
+	if err != nil {
+		return 0, fmt.Errorf("can't purge deleted persons: %w", dbError(err))
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("can't confirm how many persons are purged: %w", err)
+	}
+
+	return purged, nil
+}
+
+// missingOrStale explains why a write conditioned on the person version hasn't affected any row.
+// Writes to live persons don't see deleted ones, while a restore expects the person to be deleted.
+func (r *PSQLRepo) missingOrStale(ctx context.Context, id int, version int, deleted bool) error {
+	var current app.Person
+
+	res, err := r.runner.Select("version", "deleted_at").From("person").
+		Where("id = ?", id).LoadContext(ctx, &current)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	isDeleted := current.DeletedAt != nil
+
+	switch {
+	case res == 0, isDeleted && !deleted:
+		return fmt.Errorf("%w: person with ID %d doesn't exist", app.ErrNotFound, id)
+	case !isDeleted && deleted:
+		return fmt.Errorf("%w: person with ID %d", app.ErrNotDeleted, id)
+	}
+
+	return fmt.Errorf("%w: expected version %d, current is %d", app.ErrVersionMismatch, version, current.Version)
+}
+
+func patchColumns(patch app.PersonPatch) map[string]interface{} {
+	columns := make(map[string]interface{})
+
+	if patch.Email != nil {
+		columns["email"] = *patch.Email
+	}
+
+	if patch.Phone != nil {
+		columns["phone"] = *patch.Phone
+	}
+
+	if patch.FirstName != nil {
+		columns["first_name"] = *patch.FirstName
+	}
+
+	if patch.LastName != nil {
+		columns["last_name"] = *patch.LastName
+	}
+
+	return columns
+}
+
+// searchExpr must match the expression of the trigram index created by migrations.
+const searchExpr = "(first_name || ' ' || last_name || ' ' || email)"
+
+func (r *PSQLRepo) Search(ctx context.Context, query string, limit int) ([]app.PersonMatch, error) {
+	matches := make([]app.PersonMatch, 0, limit)
+
+	_, err := r.runner.SelectBySql(
+		"SELECT *, word_similarity(?, "+searchExpr+") AS score FROM person"+
+			" WHERE ? <% "+searchExpr+" AND deleted_at IS NULL"+
+			" ORDER BY score DESC, id LIMIT ?",
+		query, query, limit,
+	).LoadContext(ctx, &matches)
+
+	if err != nil {
+		return nil, fmt.Errorf("can't search persons: %w", dbError(err))
+	}
+
+	return matches, nil
+}
+
+var sortColumns = map[app.SortField]string{
+	app.SortByID:        "id",
+	app.SortByEmail:     "email",
+	app.SortByFirstName: "first_name",
+	app.SortByLastName:  "last_name",
+	app.SortByCreatedAt: "created_at",
+}
+
+func listStmt(stmt *dbr.SelectStmt, query app.ListQuery) (*dbr.SelectStmt, error) {
+	filter := query.Filter
+
+	if !filter.IncludeDeleted {
+		stmt.Where("deleted_at IS NULL")
+	}
+
+	if filter.Email != "" {
+		stmt.Where("email = ?", filter.Email)
+	}
+
+	if filter.PhonePrefix != "" {
+		stmt.Where("phone LIKE ?", escapeLike(filter.PhonePrefix)+"%")
+	}
+
+	if filter.FirstName != "" {
+		stmt.Where("first_name ILIKE ?", "%"+escapeLike(filter.FirstName)+"%")
+	}
+
+	if filter.LastName != "" {
+		stmt.Where("last_name ILIKE ?", "%"+escapeLike(filter.LastName)+"%")
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		stmt.Where("created_at > ?", filter.CreatedAfter)
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		stmt.Where("created_at < ?", filter.CreatedBefore)
+	}
+
+	column, ok := sortColumns[query.Sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort field %q", app.ErrValidation, query.Sort.Field)
+	}
+
+	if after := query.After; after != nil {
+		op := ">"
+		if query.Sort.Desc {
+			op = "<"
+		}
+
+		if column == "id" {
+			stmt.Where("id "+op+" ?", after.ID)
+		} else {
+			stmt.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, op), after.Value, after.ID)
+		}
+	}
+
+	stmt.OrderDir(column, !query.Sort.Desc)
+
+	if column != "id" {
+		stmt.OrderDir("id", !query.Sort.Desc)
+	}
+
+	return stmt.Limit(uint64(query.Limit)), nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
 }
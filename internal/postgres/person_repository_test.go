@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/migrate"
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/dbr/v2/dialect"
+	"github.com/stretchr/testify/require"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestRepo connects to the database from TEST_DATABASE_URL
+// and skips the test when it isn't set.
+func newTestRepo(t *testing.T) *PSQLRepo {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	repo := NewPostgresRepo(dsn, PoolOptions{MaxOpenConns: 10, MaxIdleConns: 2, ConnectTimeout: 5 * time.Second},
+		app.TxOptions{Isolation: app.Serializable, MaxRetries: 3})
+
+	migrator, err := migrate.New(repo.DB())
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(context.Background()))
+
+	return repo
+}
+
+func TestPSQLRepo_Store_Concurrent(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	email := fmt.Sprintf("concurrent-%d@example.com", time.Now().UnixNano())
+
+	t.Cleanup(func() {
+		_, err := repo.session.DeleteFrom("person").Where("email = ?", email).ExecContext(ctx)
+		require.NoError(t, err)
+	})
+
+	const n = 10
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			errs[i] = repo.Store(ctx, &app.Person{
+				Email:     email,
+				Phone:     "+1111111111",
+				FirstName: "Test",
+				LastName:  "Test",
+			})
+		}(i)
+	}
+
+	wg.Wait()
+
+	stored := 0
+
+	for _, err := range errs {
+		if err == nil {
+			stored++
+
+			continue
+		}
+
+		require.ErrorIs(t, err, app.ErrEmailConflict)
+	}
+
+	require.Equal(t, 1, stored)
+}
+
+func TestPSQLRepo_Upsert(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	email := fmt.Sprintf("upsert-%d@example.com", time.Now().UnixNano())
+
+	t.Cleanup(func() {
+		_, err := repo.session.DeleteFrom("person").Where("email = ?", email).ExecContext(ctx)
+		require.NoError(t, err)
+	})
+
+	per := &app.Person{Email: email, Phone: "+1111111111", FirstName: "Test", LastName: "Test"}
+
+	created, err := repo.Upsert(ctx, per)
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, 1, per.Version)
+
+	updated := &app.Person{Email: email, Phone: "+2222222222", FirstName: "Test", LastName: "Test"}
+
+	created, err = repo.Upsert(ctx, updated)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, per.Id, updated.Id)
+	require.Equal(t, 2, updated.Version)
+	require.Equal(t, "+2222222222", updated.Phone)
+
+	// Deleted persons don't conflict on email, so a new one is created.
+	require.NoError(t, repo.Delete(ctx, updated.Id, updated.Version))
+
+	created, err = repo.Upsert(ctx, &app.Person{Email: email, Phone: "+1111111111", FirstName: "Test", LastName: "Test"})
+	require.NoError(t, err)
+	require.True(t, created)
+}
+
+func TestPSQLRepo_SoftDelete(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	email := fmt.Sprintf("soft-delete-%d@example.com", time.Now().UnixNano())
+
+	t.Cleanup(func() {
+		_, err := repo.session.DeleteFrom("person").Where("email = ?", email).ExecContext(ctx)
+		require.NoError(t, err)
+	})
+
+	newPerson := func() *app.Person {
+		return &app.Person{Email: email, Phone: "+1111111111", FirstName: "Test", LastName: "Test"}
+	}
+
+	deleted := newPerson()
+	require.NoError(t, repo.Store(ctx, deleted))
+	require.NoError(t, repo.Delete(ctx, deleted.Id, deleted.Version))
+
+	_, err := repo.GetByID(ctx, deleted.Id, false)
+	require.ErrorIs(t, err, app.ErrNotFound)
+
+	found, err := repo.GetByID(ctx, deleted.Id, true)
+	require.NoError(t, err)
+	require.NotNil(t, found.DeletedAt)
+
+	// The email of a deleted person is free to use, so it can't be restored while taken.
+	require.NoError(t, repo.Store(ctx, newPerson()))
+
+	_, err = repo.Restore(ctx, deleted.Id, found.Version)
+	require.ErrorIs(t, err, app.ErrEmailConflict)
+
+	// The database may be shared, so the person is deleted long ago for the purge to reach it alone.
+	deletedAt := time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err = repo.session.Update("person").Set("deleted_at", deletedAt).Where("id = ?", deleted.Id).ExecContext(ctx)
+	require.NoError(t, err)
+
+	purged, err := repo.Purge(ctx, deletedAt.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+
+	_, err = repo.GetByID(ctx, deleted.Id, true)
+	require.ErrorIs(t, err, app.ErrNotFound)
+}
+
+func TestPSQLRepo_WithinTx(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	email := fmt.Sprintf("tx-%d@example.com", time.Now().UnixNano())
+	errRollback := errors.New("rollback")
+
+	var stored app.Person
+
+	err := repo.WithinTx(ctx, func(tx app.PersonRepository) error {
+		stored = app.Person{Email: email, Phone: "+1111111111", FirstName: "Test", LastName: "Test"}
+		require.NoError(t, tx.Store(ctx, &stored))
+
+		changes, err := app.Diff(nil, &stored)
+		require.NoError(t, err)
+		require.NoError(t, tx.AddAudit(ctx, &app.AuditRecord{PersonID: stored.Id, Action: app.ActionCreate, Changes: changes}))
+
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	_, err = repo.GetByID(ctx, stored.Id, true)
+	require.ErrorIs(t, err, app.ErrNotFound)
+
+	history, err := repo.GetHistory(ctx, stored.Id)
+	require.NoError(t, err)
+	require.Empty(t, history)
+}
+
+func TestPSQLRepo_Check(t *testing.T) {
+	repo := newTestRepo(t)
+
+	details, err := repo.Check(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 10, details.(PoolStats).MaxOpen)
+
+	require.NoError(t, repo.Close())
+
+	_, err = repo.Check(context.Background())
+	require.Error(t, err)
+}
+
+func TestListStmt(t *testing.T) {
+	testTable := []struct {
+		name        string
+		query       app.ListQuery
+		expectedSQL string
+	}{
+		{
+			name:        "First Page",
+			query:       app.ListQuery{Sort: app.Sort{Field: app.SortByID}, Limit: 3},
+			expectedSQL: `SELECT * FROM person WHERE (deleted_at IS NULL) ORDER BY id ASC LIMIT 3`,
+		}, {
+			name:        "Next Page By ID",
+			query:       app.ListQuery{Sort: app.Sort{Field: app.SortByID}, After: &app.Cursor{ID: 7}, Limit: 3},
+			expectedSQL: `SELECT * FROM person WHERE (deleted_at IS NULL) AND (id > 7) ORDER BY id ASC LIMIT 3`,
+		}, {
+			name: "Filtered Next Page By Last Name Descending",
+			query: app.ListQuery{
+				Filter: app.PersonFilter{
+					Email:        "test@gmail.com",
+					PhonePrefix:  "+1",
+					FirstName:    "50%_off",
+					CreatedAfter: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+				Sort:  app.Sort{Field: app.SortByLastName, Desc: true},
+				After: &app.Cursor{ID: 7, Value: "O'Brien"},
+				Limit: 3,
+			},
+			expectedSQL: `SELECT * FROM person WHERE (deleted_at IS NULL) AND (email = 'test@gmail.com') AND (phone LIKE '+1%') AND (first_name ILIKE '%50\%\_off%') ` +
+				`AND (created_at > '2022-01-01 00:00:00.000000') AND ((last_name, id) < ('O''Brien', 7)) ORDER BY last_name DESC, id DESC LIMIT 3`,
+		}, {
+			name: "Including Deleted",
+			query: app.ListQuery{
+				Filter: app.PersonFilter{IncludeDeleted: true},
+				Sort:   app.Sort{Field: app.SortByID},
+				Limit:  3,
+			},
+			expectedSQL: `SELECT * FROM person ORDER BY id ASC LIMIT 3`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			stmt, err := listStmt(dbr.Select("*").From("person"), testCase.query)
+			require.NoError(t, err)
+
+			buf := dbr.NewBuffer()
+			require.NoError(t, stmt.Build(dialect.PostgreSQL, buf))
+
+			sql, err := dbr.InterpolateForDialect(buf.String(), buf.Value(), dialect.PostgreSQL)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedSQL, sql)
+		})
+	}
+}
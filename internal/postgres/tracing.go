@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
+	"github.com/gocraft/dbr/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"unicode"
+)
+
+// queryTracer is the dbr event receiver making a span of every query, named by the SQL operation.
+// The spans are made with the global tracer provider, so that queries aren't traced until it is set up.
+// dbr interpolates the arguments into the statements, so they are recorded with the literals replaced by ?
+// not to send person data along with the spans.
+// Failed queries are logged with the logger of the request too, at debug level as the error handler logs
+// the error the request fails with.
+type queryTracer struct {
+	dbr.NullEventReceiver
+}
+
+type statementKey struct{}
+
+var _ dbr.TracingEventReceiver = (*queryTracer)(nil)
+
+func (t *queryTracer) SpanStart(ctx context.Context, eventName, query string) context.Context {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	operation = strings.ToUpper(operation)
+	statement := parameterize(query)
+
+	ctx, _ = otel.Tracer("github.com/EgorMamoshkin/person-api-crud/internal/postgres").Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationKey.String(operation),
+			semconv.DBStatementKey.String(statement),
+		))
+
+	return context.WithValue(ctx, statementKey{}, statement)
+}
+
+func (t *queryTracer) SpanError(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	statement, _ := ctx.Value(statementKey{}).(string)
+	logging.FromContext(ctx).WithField("statement", statement).WithError(err).Debug("query failed")
+}
+
+func (t *queryTracer) SpanFinish(ctx context.Context) {
+	trace.SpanFromContext(ctx).End()
+}
+
+// parameterize replaces the string and number literals of the query with ?, keeping quoted identifiers.
+// Strings are the way dbr encodes texts, times and bytes, the latter with the E prefix.
+func parameterize(query string) string {
+	var b strings.Builder
+
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '\'' || ((r == 'E' || r == 'e') && i+1 < len(runes) && runes[i+1] == '\'' && !identRune(runes, i-1)):
+			if r != '\'' {
+				i++
+			}
+
+			// Quotes are escaped by doubling them, the literal ends at a quote not followed by another one.
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i++
+
+						continue
+					}
+
+					break
+				}
+			}
+
+			b.WriteRune('?')
+		case r == '"':
+			// Identifiers are kept as they are.
+			b.WriteRune(r)
+
+			for i++; i < len(runes); i++ {
+				b.WriteRune(runes[i])
+
+				if runes[i] == '"' {
+					break
+				}
+			}
+		case unicode.IsDigit(r) && !identRune(runes, i-1):
+			for i+1 < len(runes) && (unicode.IsDigit(runes[i+1]) || runes[i+1] == '.') {
+				i++
+			}
+
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// identRune tells whether the rune at i is a part of an identifier or a keyword.
+func identRune(runes []rune, i int) bool {
+	return i >= 0 && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$')
+}
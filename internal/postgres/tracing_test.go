@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestQueryTracer_SpanError(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	ctx := logging.NewContext(context.Background(), logger.WithField("request_id", "req-1"))
+
+	tracer := &queryTracer{}
+
+	ctx = tracer.SpanStart(ctx, "dbr.select", `SELECT * FROM "person" WHERE (id = 1)`)
+	tracer.SpanError(ctx, errors.New("connection refused"))
+	tracer.SpanFinish(ctx)
+
+	require.Len(t, hook.AllEntries(), 1)
+	require.Equal(t, logrus.DebugLevel, hook.LastEntry().Level)
+	require.Equal(t, "req-1", hook.LastEntry().Data["request_id"], "failed queries must be logged with the request ID")
+	require.Equal(t, `SELECT * FROM "person" WHERE (id = ?)`, hook.LastEntry().Data["statement"])
+}
+
+func TestParameterize(t *testing.T) {
+	testTable := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "Strings And Numbers",
+			query:    `SELECT * FROM "person" WHERE (email = 'o''neil@example.com') AND (id > 42) LIMIT 11`,
+			expected: `SELECT * FROM "person" WHERE (email = ?) AND (id > ?) LIMIT ?`,
+		}, {
+			name:     "Bytes And Times",
+			query:    `UPDATE "person" SET "blob" = E'\\x0a27', "deleted_at" = '2022-11-01 12:00:00.000000' WHERE (version = 3.5)`,
+			expected: `UPDATE "person" SET "blob" = ?, "deleted_at" = ? WHERE (version = ?)`,
+		}, {
+			name:     "Identifiers",
+			query:    `INSERT INTO "person_audit1" ("v2", "it's") VALUES (TRUE, NULL)`,
+			expected: `INSERT INTO "person_audit1" ("v2", "it's") VALUES (TRUE, NULL)`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.Equal(t, testCase.expected, parameterize(testCase.query))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
+	"github.com/gocraft/dbr/v2"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"math/rand"
+	"time"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+var isolationLevels = map[app.IsolationLevel]sql.IsolationLevel{
+	app.ReadCommitted:  sql.LevelReadCommitted,
+	app.RepeatableRead: sql.LevelRepeatableRead,
+	app.Serializable:   sql.LevelSerializable,
+}
+
+// retryBackoff is the base delay before running a failed transaction again, it grows with every attempt.
+const retryBackoff = 10 * time.Millisecond
+
+func (r *PSQLRepo) WithinTx(ctx context.Context, fn func(repo app.PersonRepository) error) error {
+	if _, ok := r.runner.(*dbr.Tx); ok {
+		return fn(r)
+	}
+
+	opts := &sql.TxOptions{Isolation: isolationLevels[r.txOpts.Isolation]}
+
+	return retrySerialization(ctx, r.txOpts.MaxRetries, func() error {
+		return r.runTx(ctx, opts, fn)
+	})
+}
+
+func (r *PSQLRepo) runTx(ctx context.Context, opts *sql.TxOptions, fn func(repo app.PersonRepository) error) error {
+	tx, err := r.session.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", dbError(err))
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	if err := fn(&PSQLRepo{session: r.session, runner: tx, txOpts: r.txOpts}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can't commit transaction: %w", dbError(err))
+	}
+
+	return nil
+}
+
+// retrySerialization runs fn again while it fails to serialize with concurrent transactions,
+// waiting a little longer before every attempt.
+func retrySerialization(ctx context.Context, maxRetries int, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if !isSerializationFailure(err) {
+			return err
+		}
+
+		if attempt >= maxRetries {
+			return fmt.Errorf("%w: gave up after %d attempts: %s", app.ErrConcurrentUpdate, attempt+1, err)
+		}
+
+		backoff := time.Duration(attempt+1)*retryBackoff + time.Duration(rand.Int63n(int64(retryBackoff)))
+
+		logging.FromContext(ctx).WithFields(logrus.Fields{"attempt": attempt + 1, "backoff": backoff.String()}).
+			Warnf("transaction conflicted with a concurrent one, retrying: %s", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s", app.ErrConcurrentUpdate, err)
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && (pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected)
+}
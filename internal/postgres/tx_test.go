@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRetrySerialization(t *testing.T) {
+	conflict := &pq.Error{Code: serializationFailure}
+	other := errors.New("other failure")
+
+	testTable := []struct {
+		name             string
+		errs             []error
+		maxRetries       int
+		expectedAttempts int
+		expectedErr      error
+	}{
+		{
+			name:             "OK",
+			errs:             []error{nil},
+			maxRetries:       3,
+			expectedAttempts: 1,
+		}, {
+			name:             "Retried",
+			errs:             []error{conflict, &pq.Error{Code: deadlockDetected}, nil},
+			maxRetries:       3,
+			expectedAttempts: 3,
+		}, {
+			name:             "Gave Up",
+			errs:             []error{conflict, conflict, conflict},
+			maxRetries:       2,
+			expectedAttempts: 3,
+			expectedErr:      app.ErrConcurrentUpdate,
+		}, {
+			name:             "Not Retryable",
+			errs:             []error{other},
+			maxRetries:       3,
+			expectedAttempts: 1,
+			expectedErr:      other,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			attempts := 0
+
+			err := retrySerialization(context.Background(), testCase.maxRetries, func() error {
+				err := testCase.errs[attempts]
+				attempts++
+
+				return err
+			})
+
+			require.Equal(t, testCase.expectedAttempts, attempts)
+
+			if testCase.expectedErr != nil {
+				require.ErrorIs(t, err, testCase.expectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package tracing
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const instrumentation = "github.com/EgorMamoshkin/person-api-crud/internal/tracing"
+
+// PersonLogic is an app.PersonLogic making a span of every call to the wrapped one.
+type PersonLogic struct {
+	logic  app.PersonLogic
+	tracer trace.Tracer
+}
+
+// NewPersonLogic traces the logic with the tracer provider, the global one if it is nil.
+func NewPersonLogic(logic app.PersonLogic, provider trace.TracerProvider) *PersonLogic {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return &PersonLogic{logic: logic, tracer: provider.Tracer(instrumentation)}
+}
+
+func (l *PersonLogic) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return l.tracer.Start(ctx, "PersonLogic."+method, trace.WithAttributes(attrs...))
+}
+
+// end ends the span, recording the error the call has returned.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+var (
+	personIDKey = attribute.Key("person.id")
+	bulkModeKey = attribute.Key("bulk.mode")
+	bulkSizeKey = attribute.Key("bulk.size")
+)
+
+func (l *PersonLogic) StorePerson(ctx context.Context, per *app.Person) error {
+	ctx, span := l.start(ctx, "StorePerson")
+
+	err := l.logic.StorePerson(ctx, per)
+	if err == nil {
+		span.SetAttributes(personIDKey.Int(per.Id))
+	}
+
+	end(span, err)
+
+	return err
+}
+
+func (l *PersonLogic) DeletePerson(ctx context.Context, id int, version int) error {
+	ctx, span := l.start(ctx, "DeletePerson", personIDKey.Int(id))
+
+	err := l.logic.DeletePerson(ctx, id, version)
+	end(span, err)
+
+	return err
+}
+
+func (l *PersonLogic) GetPersonByID(ctx context.Context, id int, includeDeleted bool) (*app.Person, error) {
+	ctx, span := l.start(ctx, "GetPersonByID", personIDKey.Int(id))
+
+	person, err := l.logic.GetPersonByID(ctx, id, includeDeleted)
+	end(span, err)
+
+	return person, err
+}
+
+func (l *PersonLogic) UpdatePerson(ctx context.Context, per *app.Person) error {
+	ctx, span := l.start(ctx, "UpdatePerson", personIDKey.Int(per.Id))
+
+	err := l.logic.UpdatePerson(ctx, per)
+	end(span, err)
+
+	return err
+}
+
+func (l *PersonLogic) PatchPerson(ctx context.Context, id int, version int, patch app.PersonPatch) (*app.Person, error) {
+	ctx, span := l.start(ctx, "PatchPerson", personIDKey.Int(id))
+
+	person, err := l.logic.PatchPerson(ctx, id, version, patch)
+	end(span, err)
+
+	return person, err
+}
+
+func (l *PersonLogic) GetPersonList(ctx context.Context, req app.ListRequest) (*app.PersonPage, error) {
+	ctx, span := l.start(ctx, "GetPersonList")
+
+	page, err := l.logic.GetPersonList(ctx, req)
+	end(span, err)
+
+	return page, err
+}
+
+func (l *PersonLogic) SearchPersons(ctx context.Context, query string, limit int) ([]app.PersonMatch, error) {
+	ctx, span := l.start(ctx, "SearchPersons")
+
+	matches, err := l.logic.SearchPersons(ctx, query, limit)
+	end(span, err)
+
+	return matches, err
+}
+
+func (l *PersonLogic) RestorePerson(ctx context.Context, id int, version int) (*app.Person, error) {
+	ctx, span := l.start(ctx, "RestorePerson", personIDKey.Int(id))
+
+	person, err := l.logic.RestorePerson(ctx, id, version)
+	end(span, err)
+
+	return person, err
+}
+
+func (l *PersonLogic) GetPersonHistory(ctx context.Context, id int) ([]app.AuditRecord, error) {
+	ctx, span := l.start(ctx, "GetPersonHistory", personIDKey.Int(id))
+
+	history, err := l.logic.GetPersonHistory(ctx, id)
+	end(span, err)
+
+	return history, err
+}
+
+func (l *PersonLogic) GetPersonAsOf(ctx context.Context, id int, t time.Time) (*app.Person, error) {
+	ctx, span := l.start(ctx, "GetPersonAsOf", personIDKey.Int(id))
+
+	person, err := l.logic.GetPersonAsOf(ctx, id, t)
+	end(span, err)
+
+	return person, err
+}
+
+func (l *PersonLogic) StorePersons(ctx context.Context, persons []app.Person, mode app.BulkMode) ([]app.BulkResult, error) {
+	ctx, span := l.start(ctx, "StorePersons", bulkModeKey.String(string(mode)), bulkSizeKey.Int(len(persons)))
+
+	results, err := l.logic.StorePersons(ctx, persons, mode)
+	end(span, err)
+
+	return results, err
+}
+
+func (l *PersonLogic) UpdatePersons(ctx context.Context, persons []app.Person, mode app.BulkMode) ([]app.BulkResult, error) {
+	ctx, span := l.start(ctx, "UpdatePersons", bulkModeKey.String(string(mode)), bulkSizeKey.Int(len(persons)))
+
+	results, err := l.logic.UpdatePersons(ctx, persons, mode)
+	end(span, err)
+
+	return results, err
+}
+
+func (l *PersonLogic) DeletePersons(ctx context.Context, refs []app.PersonRef, mode app.BulkMode) ([]app.BulkResult, error) {
+	ctx, span := l.start(ctx, "DeletePersons", bulkModeKey.String(string(mode)), bulkSizeKey.Int(len(refs)))
+
+	results, err := l.logic.DeletePersons(ctx, refs, mode)
+	end(span, err)
+
+	return results, err
+}
+
+func (l *PersonLogic) ImportPerson(ctx context.Context, per *app.Person, opts app.ImportOptions) (app.ImportOutcome, error) {
+	ctx, span := l.start(ctx, "ImportPerson", attribute.Bool("import.dry_run", opts.DryRun))
+
+	outcome, err := l.logic.ImportPerson(ctx, per, opts)
+	if err == nil {
+		span.SetAttributes(attribute.String("import.outcome", string(outcome)))
+	}
+
+	end(span, err)
+
+	return outcome, err
+}
+
+func (l *PersonLogic) Upsert(ctx context.Context, per *app.Person) (bool, error) {
+	ctx, span := l.start(ctx, "Upsert")
+
+	created, err := l.logic.Upsert(ctx, per)
+	if err == nil {
+		span.SetAttributes(personIDKey.Int(per.Id), attribute.Bool("person.created", created))
+	}
+
+	end(span, err)
+
+	return created, err
+}
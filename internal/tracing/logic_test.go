@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logic"
+	"github.com/EgorMamoshkin/person-api-crud/internal/memory"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+	"time"
+)
+
+func TestPersonLogic(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	perLogic := NewPersonLogic(logic.NewPersonLogic(memory.NewPersonRepo(), time.Second, ""),
+		NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	ctx := context.Background()
+
+	per := &app.Person{Email: "john@example.com", Phone: "+14155550101", FirstName: "John", LastName: "Smith"}
+	require.NoError(t, perLogic.StorePerson(ctx, per))
+
+	_, err := perLogic.GetPersonByID(ctx, per.Id+1, false)
+	require.ErrorIs(t, err, app.ErrNotFound)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	require.Equal(t, "PersonLogic.StorePerson", spans[0].Name)
+	require.Contains(t, spans[0].Attributes, personIDKey.Int(per.Id))
+	require.Equal(t, codes.Unset, spans[0].Status.Code)
+
+	require.Equal(t, "PersonLogic.GetPersonByID", spans[1].Name)
+	require.Equal(t, codes.Error, spans[1].Status.Code)
+	require.Len(t, spans[1].Events, 1, "the error must be recorded")
+}
+
+func TestInit(t *testing.T) {
+	shutdown, err := Init(context.Background(), ExporterNone)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = Init(context.Background(), "jaeger")
+	require.EqualError(t, err, `unknown trace exporter "jaeger"`)
+}
@@ -1,31 +1,252 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/EgorMamoshkin/person-api-crud/internal/app"
 	"github.com/EgorMamoshkin/person-api-crud/internal/config"
+	"github.com/EgorMamoshkin/person-api-crud/internal/health"
 	handlers "github.com/EgorMamoshkin/person-api-crud/internal/http"
+	"github.com/EgorMamoshkin/person-api-crud/internal/lifecycle"
+	"github.com/EgorMamoshkin/person-api-crud/internal/logging"
 	"github.com/EgorMamoshkin/person-api-crud/internal/logic"
+	"github.com/EgorMamoshkin/person-api-crud/internal/metrics"
+	"github.com/EgorMamoshkin/person-api-crud/internal/migrate"
 	"github.com/EgorMamoshkin/person-api-crud/internal/postgres"
+	"github.com/EgorMamoshkin/person-api-crud/internal/tracing"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 )
 
 func main() {
-	cfg, err := config.Init()
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, pflag.ErrHelp) {
+		return
+	}
+
+	var invalid *config.ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		logrus.Fatal(err)
+	}
+
+	// The configuration is printed even if it is invalid, which helps to find out why.
+	if len(args) > 0 && args[0] == "config" {
+		if err := runConfig(cfg, args[1:]); err != nil {
+			logrus.Fatal(err)
+		}
+
+		if invalid != nil {
+			logrus.Fatal(invalid)
+		}
+
+		return
+	}
+
 	if err != nil {
 		logrus.Fatal(err)
 	}
 
-	dsn := fmt.Sprintf("postgres://%s:%s@%s?sslmode=disable", cfg.DBUser, cfg.DBPass, cfg.DBPath)
+	if err := logging.Setup(cfg.Log.Level, cfg.Log.Format); err != nil {
+		logrus.Fatal(err)
+	}
 
-	db := postgres.NewPostgresRepo(dsn)
+	db := postgres.NewPostgresRepo(cfg.DB.DSN(), postgres.PoolOptions{
+		MaxOpenConns:    cfg.DB.MaxOpenConns,
+		MaxIdleConns:    cfg.DB.MaxIdleConns,
+		ConnMaxLifetime: cfg.DB.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.DB.ConnMaxIdleTime,
+		ConnectTimeout:  cfg.DB.ConnectTimeout,
+	}, cfg.DB.TxOptions())
 
-	perLogic := logic.NewPersonLogic(db, 5*time.Second)
+	migrator, err := migrate.New(db.DB())
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		err := runMigrate(migrator, args[1:])
+
+		if closeErr := db.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		return
+	}
+
+	if len(args) > 0 {
+		logrus.Fatalf("unknown command: %s", args[0])
+	}
+
+	if cfg.Features.AutoMigrate {
+		if err := migrator.Up(context.Background()); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Trace.Exporter)
+	if err != nil {
+		logrus.Fatal(err)
+	}
 
 	e := echo.New()
+	e.Server.ReadTimeout = cfg.Server.ReadTimeout
+	e.Server.ReadHeaderTimeout = cfg.Server.ReadHeaderTimeout
+	e.Server.WriteTimeout = cfg.Server.WriteTimeout
+	e.Server.IdleTimeout = cfg.Server.IdleTimeout
 
-	handlers.NewPersonHandler(e, perLogic)
+	var (
+		repo app.PersonRepository = db
+		m    *metrics.Metrics
+	)
+
+	if cfg.Features.Metrics {
+		m = metrics.New()
+		if err := m.RegisterDB(db.DB(), "person"); err != nil {
+			logrus.Fatal(err)
+		}
+
+		repo = metrics.NewPersonRepo(db, m)
+
+		handlers.NewMetricsHandler(e, m)
+	}
+
+	// core is kept to apply the reloaded timeout.
+	core := logic.NewPersonLogic(repo, cfg.Logic.Timeout, cfg.Logic.PhoneRegion)
+
+	var perLogic app.PersonLogic = tracing.NewPersonLogic(core, nil)
+
+	if m != nil {
+		perLogic = metrics.NewPersonLogic(perLogic, m)
+	}
 
-	logrus.Fatal(e.Start(cfg.ApiServAddr))
+	handlers.NewPersonHandler(e, perLogic, cfg.Logic.PhoneRegion,
+		handlers.Admins{Names: cfg.Server.Admins, Proxies: cfg.Server.TrustedProxies})
+
+	registry := health.NewRegistry(2 * time.Second)
+	registry.Register("database", db.Check)
+
+	handlers.NewHealthHandler(e, registry)
+
+	// Components are stopped in reverse order: the server drains requests first, the logs are flushed last.
+	components := lifecycle.New()
+	components.Add("logs", lifecycle.Func{OnStop: func(context.Context) error {
+		logrus.Info("shutdown complete")
+
+		return syncLogs()
+	}})
+	components.Add("tracing", lifecycle.Func{OnStop: shutdownTracing})
+	components.Add("database", lifecycle.Func{OnStop: func(context.Context) error {
+		return db.Close()
+	}})
+
+	// The purger runs even if purging is disabled, so that it can be enabled by reloading the configuration.
+	purger := logic.NewPurger(repo, cfg.Purge.Retention, cfg.Purge.Interval, cfg.Purge.Timeout)
+	purger.SetEnabled(cfg.Features.Purge)
+	components.Add("purger", lifecycle.NewWorker(purger.Run))
+
+	watcher := config.NewWatcher(os.Args[1:], cfg, func(cfg *config.Config) {
+		// The configuration is valid, so are its log settings.
+		if err := logging.Setup(cfg.Log.Level, cfg.Log.Format); err != nil {
+			logrus.Error(err)
+		}
+
+		core.SetTimeout(cfg.Logic.Timeout)
+		purger.SetEnabled(cfg.Features.Purge)
+	})
+	components.Add("config watcher", lifecycle.NewWorker(watcher.Run))
+
+	components.Add("http server", lifecycle.Func{
+		OnStart: func() error {
+			if err := e.Start(cfg.Server.Addr); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+
+			return nil
+		},
+		OnStop: e.Shutdown,
+	})
+	// Stopped before the server, so that load balancers see the service as not ready while it still serves requests.
+	components.Add("readiness", lifecycle.Func{OnStop: func(ctx context.Context) error {
+		return registry.Drain(ctx, cfg.Server.DrainDelay)
+	}})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := components.Run(ctx, cfg.Server.ShutdownTimeout); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// syncLogs flushes the log file the logs may be redirected to, terminals and pipes can't be synced.
+func syncLogs() error {
+	f, ok := logrus.StandardLogger().Out.(*os.File)
+	if !ok {
+		return nil
+	}
+
+	if err := f.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTSUP) {
+		return err
+	}
+
+	return nil
+}
+
+// runConfig handles "config print".
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("usage: %s config print", os.Args[0])
+	}
+
+	return config.Print(os.Stdout, cfg)
+}
+
+// runMigrate handles "migrate up", "migrate down [steps]" and "migrate version".
+func runMigrate(m *migrate.Migrator, args []string) error {
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s migrate up|down [steps]|version", os.Args[0])
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		steps := 1
+
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+
+			steps = n
+		}
+
+		return m.Down(ctx, steps)
+	case "version":
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(version)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command: %s", args[0])
+	}
 }